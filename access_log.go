@@ -0,0 +1,51 @@
+package httpd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// accessLogFormats are the LogFormat strings for the values of
+// BP_WEB_SERVER_ACCESS_LOG_FORMAT, except off, which disables access logs.
+// The json format writes one object per request with a stable set of keys.
+// httpd writes bytes outside of printable ASCII as \xhh sequences, which are
+// not valid in JSON, so its string values are logged from environment
+// variables that the generated configuration only sets to printable ASCII: the
+// path is taken undecoded from the request line, and headers that contain
+// other bytes are logged as "-".
+// Every format includes the request ID, which is logged from the X-Request-ID
+// response header as that is also set for requests that are rejected before
+// the request header is added.
+var accessLogFormats = map[string]string{
+	"common":   `%h %l %u %t \"%r\" %>s %b \"%{X-Request-ID}o\"`,
+	"combined": `%h %l %u %t \"%r\" %>s %b \"%{Referer}i\" \"%{User-Agent}i\" \"%{X-Request-ID}o\"`,
+	"json": `{` +
+		`\"timestamp\":\"%{%Y-%m-%dT%H:%M:%S%z}t\",` +
+		`\"method\":\"%m\",` +
+		`\"path\":\"%{ACCESS_LOG_PATH}e\",` +
+		`\"status\":%>s,` +
+		`\"bytes\":%B,` +
+		`\"duration_us\":%D,` +
+		`\"user_agent\":\"%{ACCESS_LOG_USER_AGENT}e\",` +
+		`\"forwarded_for\":\"%{ACCESS_LOG_FORWARDED_FOR}e\",` +
+		`\"request_id\":\"%{X-Request-ID}o\"` +
+		`}`,
+	"off": "",
+}
+
+// accessLogFormat returns the normalized name of the access log format set by
+// BP_WEB_SERVER_ACCESS_LOG_FORMAT and its LogFormat string. The format
+// defaults to common.
+func accessLogFormat(value string) (string, string, error) {
+	name := strings.ToLower(strings.TrimSpace(value))
+	if name == "" {
+		name = "common"
+	}
+
+	format, ok := accessLogFormats[name]
+	if !ok {
+		return "", "", fmt.Errorf("failed: BP_WEB_SERVER_ACCESS_LOG_FORMAT %q must be one of common, combined, json or off", value)
+	}
+
+	return name, format, nil
+}
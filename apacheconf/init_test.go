@@ -0,0 +1,14 @@
+package apacheconf_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitApacheConf(t *testing.T) {
+	suite := spec.New("apacheconf", spec.Report(report.Terminal{}))
+	suite("Parser", testParser)
+	suite.Run(t)
+}
@@ -0,0 +1,298 @@
+// Package apacheconf parses Apache HTTP Server config files into a tree of
+// directives so that they can be inspected without running httpd.
+package apacheconf
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var variableRegexp = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// Directive is a single directive or section in an Apache config file.
+// Sections such as <Directory> hold their contents in Block.
+type Directive struct {
+	Name  string
+	Args  []string
+	File  string
+	Line  int
+	Block []Directive
+}
+
+// Variables returns the names of the ${VAR} references in the arguments of
+// the directive in the order they appear.
+func (d Directive) Variables() []string {
+	var names []string
+	for _, arg := range d.Args {
+		for _, match := range variableRegexp.FindAllStringSubmatch(arg, -1) {
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// Walk calls fn for every directive, descending into sections.
+func Walk(directives []Directive, fn func(Directive)) {
+	for _, directive := range directives {
+		fn(directive)
+		Walk(directive.Block, fn)
+	}
+}
+
+type Parser struct {
+	env map[string]string
+}
+
+// NewParser returns a Parser that expands ${VAR} references in Include paths
+// using env and any variables set with Define.
+func NewParser(env map[string]string) Parser {
+	return Parser{
+		env: env,
+	}
+}
+
+// ParseFile parses the config at path, replacing Include and IncludeOptional
+// directives with the directives of the files they name.
+func (p Parser) ParseFile(path string) ([]Directive, error) {
+	state := &parseState{
+		serverRoot: filepath.Dir(path),
+		variables:  map[string]string{},
+		including:  map[string]bool{},
+	}
+	for name, value := range p.env {
+		state.variables[name] = value
+	}
+
+	return state.parseFile(path, false)
+}
+
+type parseState struct {
+	serverRoot string
+	variables  map[string]string
+	including  map[string]bool
+}
+
+type frame struct {
+	directive Directive
+	block     []Directive
+}
+
+func (s *parseState) parseFile(path string, optional bool) ([]Directive, error) {
+	if s.including[path] {
+		return nil, fmt.Errorf("%s includes itself", path)
+	}
+	s.including[path] = true
+	defer delete(s.including, path)
+
+	file, err := os.Open(path)
+	if err != nil {
+		if optional && os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	stack := []frame{{}}
+	scanner := bufio.NewScanner(file)
+
+	var lineNumber int
+	for scanner.Scan() {
+		lineNumber++
+		start := lineNumber
+		line := scanner.Text()
+		for strings.HasSuffix(line, "\\") && scanner.Scan() {
+			lineNumber++
+			line = strings.TrimSuffix(line, "\\") + scanner.Text()
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "</") {
+			name := strings.TrimSuffix(strings.TrimPrefix(line, "</"), ">")
+			top := stack[len(stack)-1]
+			if len(stack) == 1 || !strings.EqualFold(top.directive.Name, name) {
+				return nil, fmt.Errorf("%s:%d: unexpected </%s>", path, start, name)
+			}
+
+			stack = stack[:len(stack)-1]
+			top.directive.Block = top.block
+			stack[len(stack)-1].block = append(stack[len(stack)-1].block, top.directive)
+			continue
+		}
+
+		if strings.HasPrefix(line, "<") {
+			if !strings.HasSuffix(line, ">") {
+				return nil, fmt.Errorf("%s:%d: section %q is missing a closing '>'", path, start, line)
+			}
+
+			args, err := splitArgs(strings.TrimSuffix(strings.TrimPrefix(line, "<"), ">"))
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, start, err)
+			}
+
+			stack = append(stack, frame{directive: Directive{Name: args[0], Args: args[1:], File: path, Line: start}})
+			continue
+		}
+
+		args, err := splitArgs(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, start, err)
+		}
+
+		directive := Directive{Name: args[0], Args: args[1:], File: path, Line: start}
+		top := &stack[len(stack)-1]
+
+		switch strings.ToLower(directive.Name) {
+		case "serverroot":
+			if len(directive.Args) > 0 {
+				s.serverRoot = s.expand(directive.Args[0])
+			}
+
+		case "define":
+			if len(directive.Args) > 1 {
+				s.variables[directive.Args[0]] = s.expand(directive.Args[1])
+			} else if len(directive.Args) == 1 {
+				s.variables[directive.Args[0]] = ""
+			}
+
+		case "include", "includeoptional":
+			if len(directive.Args) == 0 {
+				return nil, fmt.Errorf("%s:%d: %s requires a path", path, start, directive.Name)
+			}
+
+			included, err := s.include(s.expand(directive.Args[0]), strings.EqualFold(directive.Name, "includeoptional"))
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, start, err)
+			}
+
+			top.block = append(top.block, included...)
+			continue
+		}
+
+		top.block = append(top.block, directive)
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stack) > 1 {
+		top := stack[len(stack)-1].directive
+		return nil, fmt.Errorf("%s:%d: <%s> is not closed", path, top.Line, top.Name)
+	}
+
+	return stack[0].block, nil
+}
+
+func (s *parseState) include(pattern string, optional bool) ([]Directive, error) {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(s.serverRoot, pattern)
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matches) == 0 {
+		if optional || strings.ContainsAny(pattern, "*?[") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("included file %s does not exist", pattern)
+	}
+
+	sort.Strings(matches)
+
+	var directives []Directive
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil {
+			return nil, err
+		}
+
+		if info.IsDir() {
+			continue
+		}
+
+		included, err := s.parseFile(match, optional)
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, included...)
+	}
+
+	return directives, nil
+}
+
+// expand replaces the ${VAR} references that have a known value and leaves
+// the others untouched.
+func (s *parseState) expand(value string) string {
+	return variableRegexp.ReplaceAllStringFunc(value, func(reference string) string {
+		if v, ok := s.variables[reference[2:len(reference)-1]]; ok {
+			return v
+		}
+		return reference
+	})
+}
+
+// splitArgs splits a directive line into its name and arguments, honouring
+// double and single quotes and backslash escapes inside quotes.
+func splitArgs(line string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		quote   rune
+		inArg   bool
+		escaped bool
+	)
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case quote != 0 && r == '\\':
+			escaped = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case (r == '"' || r == '\'') && !inArg:
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", line)
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty directive")
+	}
+
+	return args, nil
+}
@@ -0,0 +1,165 @@
+package apacheconf_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/httpd/apacheconf"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testParser(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		workingDir string
+		parser     apacheconf.Parser
+	)
+
+	it.Before(func() {
+		var err error
+		workingDir, err = os.MkdirTemp("", "working-dir")
+		Expect(err).NotTo(HaveOccurred())
+
+		parser = apacheconf.NewParser(map[string]string{"APP_ROOT": workingDir})
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	context("ParseFile", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "httpd.conf"), []byte(`# some comment
+ServerRoot "${SERVER_ROOT}"
+Listen "${PORT}"
+
+<Directory "${APP_ROOT}/public">
+  Require all granted
+  <IfModule mod_rewrite.c>
+    RewriteRule (.*) \
+      index.html
+  </IfModule>
+</Directory>
+
+Header set X-Some-Header 'some "quoted" value'
+Include ${APP_ROOT}/conf.d/*.conf
+IncludeOptional ${APP_ROOT}/missing/*.conf
+`), 0600)).To(Succeed())
+
+			Expect(os.MkdirAll(filepath.Join(workingDir, "conf.d"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "conf.d", "b.conf"), []byte("LogLevel warn\n"), 0600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "conf.d", "a.conf"), []byte("ErrorLog /proc/self/fd/2\n"), 0600)).To(Succeed())
+		})
+
+		it("returns the directives with includes inlined", func() {
+			path := filepath.Join(workingDir, "httpd.conf")
+
+			directives, err := parser.ParseFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(directives).To(Equal([]apacheconf.Directive{
+				{Name: "ServerRoot", Args: []string{"${SERVER_ROOT}"}, File: path, Line: 2},
+				{Name: "Listen", Args: []string{"${PORT}"}, File: path, Line: 3},
+				{
+					Name: "Directory",
+					Args: []string{"${APP_ROOT}/public"},
+					File: path,
+					Line: 5,
+					Block: []apacheconf.Directive{
+						{Name: "Require", Args: []string{"all", "granted"}, File: path, Line: 6},
+						{
+							Name: "IfModule",
+							Args: []string{"mod_rewrite.c"},
+							File: path,
+							Line: 7,
+							Block: []apacheconf.Directive{
+								{Name: "RewriteRule", Args: []string{"(.*)", "index.html"}, File: path, Line: 8},
+							},
+						},
+					},
+				},
+				{Name: "Header", Args: []string{"set", "X-Some-Header", `some "quoted" value`}, File: path, Line: 13},
+				{Name: "ErrorLog", Args: []string{"/proc/self/fd/2"}, File: filepath.Join(workingDir, "conf.d", "a.conf"), Line: 1},
+				{Name: "LogLevel", Args: []string{"warn"}, File: filepath.Join(workingDir, "conf.d", "b.conf"), Line: 1},
+			}))
+
+			Expect(directives[1].Variables()).To(Equal([]string{"PORT"}))
+		})
+
+		context("when a relative Include is used", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "httpd.conf"), []byte(`Define CONF_DIR conf.d
+ServerRoot "${APP_ROOT}"
+Include ${CONF_DIR}/a.conf
+`), 0600)).To(Succeed())
+			})
+
+			it("resolves it against ServerRoot after expanding defined variables", func() {
+				directives, err := parser.ParseFile(filepath.Join(workingDir, "httpd.conf"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(directives).To(HaveLen(3))
+				Expect(directives[2].Name).To(Equal("ErrorLog"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when a section is not closed", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "httpd.conf"), []byte("<Directory />\n  Require all denied\n"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := parser.ParseFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).To(MatchError(filepath.Join(workingDir, "httpd.conf") + ":1: <Directory> is not closed"))
+				})
+			})
+
+			context("when a section is closed by the wrong tag", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "httpd.conf"), []byte("<Directory />\n</Files>\n"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := parser.ParseFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).To(MatchError(filepath.Join(workingDir, "httpd.conf") + ":2: unexpected </Files>"))
+				})
+			})
+
+			context("when a quote is not terminated", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "httpd.conf"), []byte("DocumentRoot \"/srv\n"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := parser.ParseFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).To(MatchError(ContainSubstring(":1: unterminated quote")))
+				})
+			})
+
+			context("when an included file does not exist", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "httpd.conf"), []byte("Include /no/such/file.conf\n"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := parser.ParseFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).To(MatchError(filepath.Join(workingDir, "httpd.conf") + ":1: included file /no/such/file.conf does not exist"))
+				})
+			})
+
+			context("when a file includes itself", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "httpd.conf"), []byte("Include ${APP_ROOT}/httpd.conf\n"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := parser.ParseFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).To(MatchError(ContainSubstring("includes itself")))
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,103 @@
+package httpd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
+)
+
+const defaultBasicAuthRealm = "Authentication Required"
+
+// BasicAuthLocation protects a path prefix with the user list from an
+// htpasswd service binding.
+type BasicAuthLocation struct {
+	Path  string
+	Realm string
+	File  string
+}
+
+// basicAuthLocations converts htpasswd bindings into locations sorted by path
+// so that more specific prefixes are emitted last and take precedence. A
+// binding without a path entry, or with a path of '/', protects the whole web
+// server root and is returned with an empty Path.
+func basicAuthLocations(bindings []servicebindings.Binding) ([]BasicAuthLocation, error) {
+	var locations []BasicAuthLocation
+	seen := map[string]string{}
+	for _, binding := range bindings {
+		if _, ok := binding.Entries[".htpasswd"]; !ok {
+			return nil, fmt.Errorf("failed: binding of type 'htpasswd' does not contain required entry '.htpasswd'")
+		}
+
+		err := validateConfigValue(fmt.Sprintf("path of binding '%s'", binding.Name), binding.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		path, err := optionalBindingEntry(binding, "path")
+		if err != nil {
+			return nil, err
+		}
+
+		if path != "" {
+			if !strings.HasPrefix(path, "/") || validateConfigValue("path", path) != nil {
+				return nil, fmt.Errorf("failed: binding '%s' of type 'htpasswd' has invalid path '%s'", binding.Name, path)
+			}
+
+			// A path of '/' protects the web server root like no path at all.
+			path = strings.Trim(path, "/")
+			if path != "" {
+				path = "/" + path
+			}
+		}
+
+		realm, err := optionalBindingEntry(binding, "realm")
+		if err != nil {
+			return nil, err
+		}
+
+		if validateConfigValue("realm", realm) != nil {
+			return nil, fmt.Errorf("failed: binding '%s' of type 'htpasswd' has invalid realm '%s'", binding.Name, realm)
+		}
+
+		if realm == "" {
+			realm = defaultBasicAuthRealm
+		}
+
+		if other, ok := seen[path]; ok {
+			if path == "" {
+				return nil, fmt.Errorf("failed: bindings '%s' and '%s' of type 'htpasswd' both protect the web server root", other, binding.Name)
+			}
+			return nil, fmt.Errorf("failed: bindings '%s' and '%s' of type 'htpasswd' both protect path '%s'", other, binding.Name, path)
+		}
+		seen[path] = binding.Name
+
+		locations = append(locations, BasicAuthLocation{
+			Path:  path,
+			Realm: realm,
+			File:  filepath.Join(binding.Path, ".htpasswd"),
+		})
+	}
+
+	sort.Slice(locations, func(i, j int) bool {
+		return locations[i].Path < locations[j].Path
+	})
+
+	return locations, nil
+}
+
+func optionalBindingEntry(binding servicebindings.Binding, name string) (string, error) {
+	entry, ok := binding.Entries[name]
+	if !ok {
+		return "", nil
+	}
+
+	value, err := entry.ReadString()
+	if err != nil {
+		return "", fmt.Errorf("failed to read entry '%s' of binding '%s': %w", name, binding.Name, err)
+	}
+
+	return strings.TrimSpace(value), nil
+}
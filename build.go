@@ -2,11 +2,13 @@ package httpd
 
 import (
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/paketo-buildpacks/packit/v2"
 	"github.com/paketo-buildpacks/packit/v2/chronos"
+	"github.com/paketo-buildpacks/packit/v2/fs"
 	"github.com/paketo-buildpacks/packit/v2/postal"
 	"github.com/paketo-buildpacks/packit/v2/sbom"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
@@ -30,32 +32,110 @@ type GenerateConfig interface {
 	Generate(workingDir, platformPath string, buildEnvironment BuildEnvironment) error
 }
 
+//go:generate faux --interface ConfigChecker --output fakes/config_checker.go
+type ConfigChecker interface {
+	Check(serverRoot, appRoot, configPath string) error
+}
+
+//go:generate faux --interface ConfigLinter --output fakes/config_linter.go
+type ConfigLinter interface {
+	Lint(serverRoot, appRoot, configPath string, strict bool) error
+}
+
+//go:generate faux --interface Precompressor --output fakes/precompressor.go
+type Precompressor interface {
+	Precompress(root string) error
+}
+
 //go:generate faux --interface SBOMGenerator --output fakes/sbom_generator.go
 type SBOMGenerator interface {
 	GenerateFromDependency(dependency postal.Dependency, dir string) (sbom.SBOM, error)
 }
 
 type BuildEnvironment struct {
-	BasicAuthFile             string
-	HTTPDVersion              string `env:"BP_HTTPD_VERSION"`
-	Reload                    bool   `env:"BP_LIVE_RELOAD_ENABLED"`
-	WebServer                 string `env:"BP_WEB_SERVER"`
-	WebServerForceHTTPS       bool   `env:"BP_WEB_SERVER_FORCE_HTTPS"`
-	WebServerPushStateEnabled bool   `env:"BP_WEB_SERVER_ENABLE_PUSH_STATE"`
-	WebServerRoot             string `env:"BP_WEB_SERVER_ROOT"`
+	AccessLogFormat                  string
+	BasicAuthFile                    string
+	BasicAuthLocations               []BasicAuthLocation
+	BasicAuthRealm                   string
+	BindingsResolved                 bool
+	CachePolicies                    []CachePolicy
+	ConfigIncludes                   []string
+	ErrorDocuments                   []ErrorDocument
+	HTTPDLintStrict                  bool   `env:"BP_HTTPD_LINT_STRICT"`
+	HTTPDVersion                     string `env:"BP_HTTPD_VERSION"`
+	HeaderRules                      []HeaderRule
+	HealthCheckFilePath              string
+	PushStateExcludePatterns         []string
+	RedirectErrorDocuments           []ScopedErrorDocument
+	Redirects                        []RedirectRule
+	RedirectsProxy                   bool
+	Reload                           bool   `env:"BP_LIVE_RELOAD_ENABLED"`
+	RoadRunnerHTTPAddress            string `env:"BP_ROADRUNNER_HTTP_ADDRESS"`
+	RoadRunnerMaxJobs                int    `env:"BP_ROADRUNNER_MAX_JOBS"`
+	RoadRunnerNumWorkers             int    `env:"BP_ROADRUNNER_NUM_WORKERS"`
+	RoadRunnerStaticDir              string `env:"BP_ROADRUNNER_STATIC_DIR"`
+	RoadRunnerVersion                string `env:"BP_ROADRUNNER_VERSION"`
+	RoadRunnerWorkerCommand          string `env:"BP_ROADRUNNER_WORKER_COMMAND"`
+	RuleFilesPresent                 bool
+	SecurityHeaders                  []SecurityHeader
+	StatusPathPattern                string
+	TLSCAFile                        string
+	TLSCertificateFile               string
+	TLSKeyFile                       string
+	WebServer                        string `env:"BP_WEB_SERVER"`
+	WebServerAccessLogFormat         string `env:"BP_WEB_SERVER_ACCESS_LOG_FORMAT"`
+	WebServerCachePolicy             string `env:"BP_WEB_SERVER_CACHE_POLICY"`
+	WebServerCompressionEnabled      bool   `env:"BP_WEB_SERVER_ENABLE_COMPRESSION"`
+	WebServerCompressionTypes        string `env:"BP_WEB_SERVER_COMPRESSION_TYPES"`
+	WebServerContentSecurityPolicy   string `env:"BP_WEB_SERVER_CONTENT_SECURITY_POLICY"`
+	WebServerErrorPages              string `env:"BP_WEB_SERVER_ERROR_PAGES"`
+	WebServerFastCGIAddress          string `env:"BP_WEB_SERVER_FASTCGI_ADDRESS"`
+	WebServerForceHTTPS              bool   `env:"BP_WEB_SERVER_FORCE_HTTPS"`
+	WebServerFrameOptions            string `env:"BP_WEB_SERVER_X_FRAME_OPTIONS"`
+	WebServerHealthCheckPath         string `env:"BP_WEB_SERVER_HEALTH_CHECK_PATH"`
+	WebServerPrecompressEnabled      bool   `env:"BP_WEB_SERVER_ENABLE_PRECOMPRESSION"`
+	WebServerProxyUpstream           string `env:"BP_WEB_SERVER_PROXY_UPSTREAM"`
+	WebServerPushStateEnabled        bool   `env:"BP_WEB_SERVER_ENABLE_PUSH_STATE"`
+	WebServerPushStateExclude        string `env:"BP_WEB_SERVER_PUSH_STATE_EXCLUDE"`
+	WebServerPushStateFallback       string `env:"BP_WEB_SERVER_PUSH_STATE_FALLBACK"`
+	WebServerReferrerPolicy          string `env:"BP_WEB_SERVER_REFERRER_POLICY"`
+	WebServerRoot                    string `env:"BP_WEB_SERVER_ROOT"`
+	WebServerSecurityHeaders         string `env:"BP_WEB_SERVER_SECURITY_HEADERS"`
+	WebServerStatusAllow             string `env:"BP_WEB_SERVER_STATUS_ALLOW"`
+	WebServerStatusPath              string `env:"BP_WEB_SERVER_STATUS_PATH"`
+	WebServerStrictTransportSecurity string `env:"BP_WEB_SERVER_STRICT_TRANSPORT_SECURITY"`
+	WebServerTLSPort                 string `env:"BP_WEB_SERVER_TLS_PORT"`
 }
 
 func Build(
 	buildEnvironment BuildEnvironment,
 	entries EntryResolver,
 	dependencies DependencyService,
+	bindingResolver BindingResolver,
 	generateConfig GenerateConfig,
+	generateRoadRunnerConfig GenerateConfig,
+	precompressor Precompressor,
+	configChecker ConfigChecker,
+	configLinter ConfigLinter,
 	sbomGenerator SBOMGenerator,
 	clock chronos.Clock,
 	logger scribe.Emitter,
 ) packit.BuildFunc {
 	return func(context packit.BuildContext) (packit.BuildResult, error) {
 		logger.Title("%s %s", context.BuildpackInfo.Name, context.BuildpackInfo.Version)
+
+		// Detection provides httpd alongside a roadrunner found in the composer
+		// files. httpd is installed instead when another buildpack requires it,
+		// unless BP_WEB_SERVER selects roadrunner.
+		roadRunner := containsEntry(context.Plan.Entries, PlanDependencyRoadRunner)
+		if roadRunner && buildEnvironment.WebServer != "roadrunner" && containsEntry(context.Plan.Entries, PlanDependencyHTTPD) {
+			roadRunner = false
+		}
+
+		if roadRunner {
+			return buildRoadRunner(context, buildEnvironment, entries, dependencies, generateRoadRunnerConfig, sbomGenerator, clock, logger)
+		}
+
 		logger.Process("Resolving Apache HTTP Server version")
 
 		priorities := []interface{}{
@@ -141,23 +221,133 @@ func Build(
 		}
 
 		if buildEnvironment.WebServer == "httpd" {
+			webServerRoot, err := resolveWebServerRoot(context.WorkingDir, buildEnvironment.WebServerRoot)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			if buildEnvironment.WebServerRoot == "" {
+				logger.Process("Discovering web server root")
+				switch {
+				case webServerRoot != "":
+					logger.Subprocess("Selected '%s', which contains an index.html", webServerRoot)
+				case !containsDir(context.WorkingDir, "public"):
+					logger.Subprocess("WARNING: none of %s contains an index.html and the default 'public' does not exist", strings.Join(webServerRootCandidates, ", "))
+				default:
+					logger.Subprocess("None of %s contains an index.html, defaulting to 'public'", strings.Join(webServerRootCandidates, ", "))
+				}
+				logger.Break()
+			}
+			buildEnvironment.WebServerRoot = webServerRoot
+			buildEnvironment.HealthCheckFilePath = filepath.Join(context.Layers.Path, LaunchConfigLayer, HealthCheckFile)
+
+			// The files from service bindings are recorded with the build
+			// environment so that the httpd.conf rendered at launch keeps them.
+			buildEnvironment, err = resolveBindings(bindingResolver, context.WorkingDir, context.Platform.Path, buildEnvironment)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
 			err = generateConfig.Generate(context.WorkingDir, context.Platform.Path, buildEnvironment)
 			if err != nil {
 				return packit.BuildResult{}, err
 			}
+
+			if buildEnvironment.WebServerPrecompressEnabled {
+				err = precompressor.Precompress(webServerSiteRoot(context.WorkingDir, buildEnvironment.WebServerRoot))
+				if err != nil {
+					return packit.BuildResult{}, err
+				}
+			}
+		}
+
+		configPath := filepath.Join(context.WorkingDir, "httpd.conf")
+		configExists, err := fs.Exists(configPath)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		// The config is linted and checked on every build, also when the httpd
+		// layer is reused, as the httpd.conf of the app may have changed since.
+		checkConfig := func(serverRoot string) error {
+			if !configExists {
+				return nil
+			}
+
+			if buildEnvironment.WebServer != "httpd" {
+				err := configLinter.Lint(serverRoot, context.WorkingDir, configPath, buildEnvironment.HTTPDLintStrict)
+				if err != nil {
+					return err
+				}
+			}
+
+			return configChecker.Check(serverRoot, context.WorkingDir, configPath)
+		}
+
+		// The exec.d programs and files that httpd uses at launch are written to
+		// a layer of their own on every build, as anything written to a reused
+		// httpd layer would replace its contents in the image.
+		installLaunchConfig := func() (packit.Layer, error) {
+			launchConfigLayer, err := context.Layers.Get(LaunchConfigLayer)
+			if err != nil {
+				return packit.Layer{}, err
+			}
+
+			launchConfigLayer, err = launchConfigLayer.Reset()
+			if err != nil {
+				return packit.Layer{}, err
+			}
+			launchConfigLayer.Launch = launch
+			launchConfigLayer.ExecD = []string{MPMTuningExecutable}
+
+			if buildEnvironment.WebServer != "httpd" {
+				return launchConfigLayer, nil
+			}
+
+			launchConfigLayer.ExecD = append(launchConfigLayer.ExecD, LaunchConfigExecutable)
+
+			err = writeBuildEnvironment(launchConfigLayer.Path, buildEnvironment)
+			if err != nil {
+				return packit.Layer{}, err
+			}
+
+			err = writeHealthCheckFile(launchConfigLayer.Path)
+			if err != nil {
+				return packit.Layer{}, err
+			}
+
+			return launchConfigLayer, nil
+		}
+
+		// A layer from a build that did not cache it is restored without its
+		// contents, which the config check needs, so httpd is installed again.
+		installed, err := fs.Exists(filepath.Join(httpdLayer.Path, "bin", "httpd"))
+		if err != nil {
+			return packit.BuildResult{}, err
 		}
 
 		cachedSHA, ok := httpdLayer.Metadata["cache_sha"].(string)
-		if ok && cachedSHA == dependency.SHA256 { //nolint:staticcheck
+		if ok && cachedSHA == dependency.SHA256 && installed { //nolint:staticcheck
 			logger.Process("Reusing cached layer %s", httpdLayer.Path)
 			logger.Break()
 
 			httpdLayer.Launch = launch
+			httpdLayer.Cache = true
+
+			err = checkConfig(httpdLayer.Path)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			launchConfigLayer, err := installLaunchConfig()
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
 
 			logger.LaunchProcesses(launchMetadata.Processes)
 
 			return packit.BuildResult{
-				Layers: []packit.Layer{httpdLayer},
+				Layers: []packit.Layer{httpdLayer, launchConfigLayer},
 				Launch: launchMetadata,
 			}, nil
 		}
@@ -169,6 +359,7 @@ func Build(
 			return packit.BuildResult{}, err
 		}
 		httpdLayer.Launch = launch
+		httpdLayer.Cache = true
 
 		logger.Subprocess("Installing Apache HTTP Server %s", dependency.Version)
 		duration, err := clock.Measure(func() error {
@@ -180,6 +371,16 @@ func Build(
 		logger.Action("Completed in %s", duration.Round(time.Millisecond))
 		logger.Break()
 
+		err = checkConfig(httpdLayer.Path)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		launchConfigLayer, err := installLaunchConfig()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
 		httpdLayer.Metadata = map[string]interface{}{
 			"cache_sha": dependency.SHA256, //nolint:staticcheck
 		}
@@ -211,8 +412,184 @@ func Build(
 		}
 
 		return packit.BuildResult{
-			Layers: []packit.Layer{httpdLayer},
+			Layers: []packit.Layer{httpdLayer, launchConfigLayer},
 			Launch: launchMetadata,
 		}, nil
 	}
 }
+
+func buildRoadRunner(
+	context packit.BuildContext,
+	buildEnvironment BuildEnvironment,
+	entries EntryResolver,
+	dependencies DependencyService,
+	generateConfig GenerateConfig,
+	sbomGenerator SBOMGenerator,
+	clock chronos.Clock,
+	logger scribe.Emitter,
+) (packit.BuildResult, error) {
+	logger.Process("Resolving RoadRunner version")
+
+	priorities := []interface{}{
+		"BP_ROADRUNNER_VERSION",
+		"composer.lock",
+	}
+	entry, sortedEntries := entries.Resolve(PlanDependencyRoadRunner, context.Plan.Entries, priorities)
+	logger.Candidates(sortedEntries)
+
+	roadRunnerLayer, err := context.Layers.Get(PlanDependencyRoadRunner)
+	if err != nil {
+		return packit.BuildResult{}, err
+	}
+
+	version, ok := entry.Metadata["version"].(string)
+	if !ok {
+		version = "*"
+	}
+
+	dependency, err := dependencies.Resolve(filepath.Join(context.CNBPath, "buildpack.toml"), PlanDependencyRoadRunner, version, context.Stack)
+	if err != nil {
+		return packit.BuildResult{}, err
+	}
+
+	logger.SelectedDependency(entry, dependency, clock.Now())
+
+	launch, _ := entries.MergeLayerTypes(PlanDependencyRoadRunner, context.Plan.Entries)
+	bom := dependencies.GenerateBillOfMaterials(dependency)
+
+	var launchMetadata packit.LaunchMetadata
+	if launch {
+		launchMetadata.BOM = bom
+	}
+
+	command := "rr"
+	args := []string{
+		"serve",
+		"-c",
+		filepath.Join(context.WorkingDir, ".rr.yaml"),
+		"-w",
+		context.WorkingDir,
+	}
+	launchMetadata.Processes = []packit.Process{
+		{
+			Type:    "web",
+			Command: command,
+			Args:    args,
+			Default: true,
+			Direct:  true,
+		},
+	}
+
+	if buildEnvironment.Reload {
+		launchMetadata.Processes = []packit.Process{
+			{
+				Type:    "web",
+				Command: "watchexec",
+				Args: append([]string{
+					"--restart",
+					"--watch", context.WorkingDir,
+					"--shell", "none",
+					"--",
+					command,
+				}, args...),
+				Default: true,
+				Direct:  true,
+			},
+			{
+				Type:    "no-reload",
+				Command: command,
+				Args:    args,
+				Direct:  true,
+			},
+		}
+	}
+
+	exists, err := fs.Exists(filepath.Join(context.WorkingDir, ".rr.yaml"))
+	if err != nil {
+		return packit.BuildResult{}, err
+	}
+
+	if !exists {
+		err = generateConfig.Generate(context.WorkingDir, context.Platform.Path, buildEnvironment)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+	}
+
+	cachedSHA, ok := roadRunnerLayer.Metadata["cache_sha"].(string)
+	if ok && cachedSHA == dependency.SHA256 { //nolint:staticcheck
+		logger.Process("Reusing cached layer %s", roadRunnerLayer.Path)
+		logger.Break()
+
+		roadRunnerLayer.Launch = launch
+
+		logger.LaunchProcesses(launchMetadata.Processes)
+
+		return packit.BuildResult{
+			Layers: []packit.Layer{roadRunnerLayer},
+			Launch: launchMetadata,
+		}, nil
+	}
+
+	logger.Process("Executing build process")
+
+	roadRunnerLayer, err = roadRunnerLayer.Reset()
+	if err != nil {
+		return packit.BuildResult{}, err
+	}
+	roadRunnerLayer.Launch = launch
+
+	logger.Subprocess("Installing RoadRunner %s", dependency.Version)
+	duration, err := clock.Measure(func() error {
+		return dependencies.Deliver(dependency, context.CNBPath, filepath.Join(roadRunnerLayer.Path, "bin"), context.Platform.Path)
+	})
+	if err != nil {
+		return packit.BuildResult{}, err
+	}
+	logger.Action("Completed in %s", duration.Round(time.Millisecond))
+	logger.Break()
+
+	roadRunnerLayer.Metadata = map[string]interface{}{
+		"cache_sha": dependency.SHA256, //nolint:staticcheck
+	}
+
+	roadRunnerLayer.LaunchEnv.Override("APP_ROOT", context.WorkingDir)
+
+	logger.EnvironmentVariables(roadRunnerLayer)
+
+	logger.LaunchProcesses(launchMetadata.Processes)
+
+	logger.GeneratingSBOM(roadRunnerLayer.Path)
+	var sbomContent sbom.SBOM
+	duration, err = clock.Measure(func() error {
+		sbomContent, err = sbomGenerator.GenerateFromDependency(dependency, roadRunnerLayer.Path)
+		return err
+	})
+	if err != nil {
+		return packit.BuildResult{}, err
+	}
+
+	logger.Action("Completed in %s", duration.Round(time.Millisecond))
+	logger.Break()
+
+	logger.FormattingSBOM(context.BuildpackInfo.SBOMFormats...)
+	roadRunnerLayer.SBOM, err = sbomContent.InFormats(context.BuildpackInfo.SBOMFormats...)
+	if err != nil {
+		return packit.BuildResult{}, err
+	}
+
+	return packit.BuildResult{
+		Layers: []packit.Layer{roadRunnerLayer},
+		Launch: launchMetadata,
+	}, nil
+}
+
+func containsEntry(entries []packit.BuildpackPlanEntry, name string) bool {
+	for _, entry := range entries {
+		if entry.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,8 +1,12 @@
 package httpd_test
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -11,6 +15,7 @@ import (
 	"github.com/paketo-buildpacks/httpd"
 	"github.com/paketo-buildpacks/httpd/fakes"
 	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
 	"github.com/paketo-buildpacks/packit/v2/chronos"
 	"github.com/paketo-buildpacks/packit/v2/sbom"
 
@@ -18,6 +23,7 @@ import (
 	"github.com/paketo-buildpacks/packit/v2/paketosbom"
 	"github.com/paketo-buildpacks/packit/v2/postal"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
 	"github.com/sclevine/spec"
 
 	. "github.com/onsi/gomega"
@@ -31,10 +37,15 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		layersDir  string
 		cnbPath    string
 
-		entryResolver     *fakes.EntryResolver
-		dependencyService *fakes.DependencyService
-		generateConfig    *fakes.GenerateConfig
-		sbomGenerator     *fakes.SBOMGenerator
+		entryResolver            *fakes.EntryResolver
+		dependencyService        *fakes.DependencyService
+		bindingResolver          *fakes.BindingResolver
+		generateConfig           *fakes.GenerateConfig
+		generateRoadRunnerConfig *fakes.GenerateConfig
+		precompressor            *fakes.Precompressor
+		configChecker            *fakes.ConfigChecker
+		configLinter             *fakes.ConfigLinter
+		sbomGenerator            *fakes.SBOMGenerator
 
 		buffer *bytes.Buffer
 
@@ -52,6 +63,9 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		cnbPath, err = os.MkdirTemp("", "cnb-path")
 		Expect(err).NotTo(HaveOccurred())
 
+		Expect(os.MkdirAll(filepath.Join(cnbPath, "bin"), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(workingDir, "httpd.conf"), nil, 0600)).To(Succeed())
+
 		entryResolver = &fakes.EntryResolver{}
 		entryResolver.ResolveCall.Returns.BuildpackPlanEntry = packit.BuildpackPlanEntry{
 			Name: "http",
@@ -87,14 +101,19 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			},
 		}
 
+		bindingResolver = &fakes.BindingResolver{}
 		generateConfig = &fakes.GenerateConfig{}
+		generateRoadRunnerConfig = &fakes.GenerateConfig{}
+		precompressor = &fakes.Precompressor{}
+		configChecker = &fakes.ConfigChecker{}
+		configLinter = &fakes.ConfigLinter{}
 
 		sbomGenerator = &fakes.SBOMGenerator{}
 		sbomGenerator.GenerateFromDependencyCall.Returns.SBOM = sbom.SBOM{}
 
 		buffer = bytes.NewBuffer(nil)
 
-		build = httpd.Build(httpd.BuildEnvironment{}, entryResolver, dependencyService, generateConfig, sbomGenerator, chronos.DefaultClock, scribe.NewEmitter(buffer))
+		build = httpd.Build(httpd.BuildEnvironment{}, entryResolver, dependencyService, bindingResolver, generateConfig, generateRoadRunnerConfig, precompressor, configChecker, configLinter, sbomGenerator, chronos.DefaultClock, scribe.NewEmitter(buffer))
 	})
 
 	it.After(func() {
@@ -130,13 +149,13 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		})
 		Expect(err).NotTo(HaveOccurred())
 
-		Expect(result.Layers).To(HaveLen(1))
+		Expect(result.Layers).To(HaveLen(2))
 		layer := result.Layers[0]
 
 		Expect(layer.Name).To(Equal("httpd"))
 		Expect(layer.Path).To(Equal(filepath.Join(layersDir, "httpd")))
 		Expect(layer.Build).To(BeFalse())
-		Expect(layer.Cache).To(BeFalse())
+		Expect(layer.Cache).To(BeTrue())
 		Expect(layer.Launch).To(BeTrue())
 		Expect(layer.LaunchEnv).To(Equal(packit.Environment{
 			"APP_ROOT.override":    workingDir,
@@ -245,6 +264,24 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 
 		Expect(generateConfig.GenerateCall.CallCount).To(Equal(0))
 
+		Expect(configChecker.CheckCall.Receives.ServerRoot).To(Equal(filepath.Join(layersDir, "httpd")))
+		Expect(configChecker.CheckCall.Receives.AppRoot).To(Equal(workingDir))
+		Expect(configChecker.CheckCall.Receives.ConfigPath).To(Equal(filepath.Join(workingDir, "httpd.conf")))
+
+		launchConfigLayer := result.Layers[1]
+		Expect(launchConfigLayer.Name).To(Equal("launch-config"))
+		Expect(launchConfigLayer.Path).To(Equal(filepath.Join(layersDir, "launch-config")))
+		Expect(launchConfigLayer.Build).To(BeFalse())
+		Expect(launchConfigLayer.Cache).To(BeFalse())
+		Expect(launchConfigLayer.Launch).To(BeTrue())
+		Expect(launchConfigLayer.ExecD).To(Equal([]string{"tune-mpm"}))
+		Expect(filepath.Join(layersDir, "launch-config", "build-environment.json")).NotTo(BeAnExistingFile())
+
+		Expect(configLinter.LintCall.Receives.ServerRoot).To(Equal(filepath.Join(layersDir, "httpd")))
+		Expect(configLinter.LintCall.Receives.AppRoot).To(Equal(workingDir))
+		Expect(configLinter.LintCall.Receives.ConfigPath).To(Equal(filepath.Join(workingDir, "httpd.conf")))
+		Expect(configLinter.LintCall.Receives.Strict).To(BeFalse())
+
 		Expect(sbomGenerator.GenerateFromDependencyCall.Receives.Dependency).To(Equal(postal.Dependency{
 			ID:           "httpd",
 			SHA256:       "some-sha", //nolint:staticcheck
@@ -305,13 +342,13 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			})
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(result.Layers).To(HaveLen(1))
+			Expect(result.Layers).To(HaveLen(2))
 			layer := result.Layers[0]
 
 			Expect(layer.Name).To(Equal("httpd"))
 			Expect(layer.Path).To(Equal(filepath.Join(layersDir, "httpd")))
 			Expect(layer.Build).To(BeFalse())
-			Expect(layer.Cache).To(BeFalse())
+			Expect(layer.Cache).To(BeTrue())
 			Expect(layer.Launch).To(BeTrue())
 			Expect(layer.LaunchEnv).To(Equal(packit.Environment{
 				"APP_ROOT.override":    workingDir,
@@ -426,7 +463,12 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 				},
 				entryResolver,
 				dependencyService,
+				bindingResolver,
 				generateConfig,
+				generateRoadRunnerConfig,
+				precompressor,
+				configChecker,
+				configLinter,
 				sbomGenerator,
 				chronos.DefaultClock,
 				scribe.NewEmitter(buffer),
@@ -460,9 +502,775 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			Expect(generateConfig.GenerateCall.Receives.WorkingDir).To(Equal(workingDir))
 			Expect(generateConfig.GenerateCall.Receives.PlatformPath).To(Equal("platform"))
 			Expect(generateConfig.GenerateCall.Receives.BuildEnvironment).To(Equal(httpd.BuildEnvironment{
-				WebServer: "httpd",
+				BindingsResolved:    true,
+				HealthCheckFilePath: filepath.Join(layersDir, "launch-config", "health.txt"),
+				WebServer:           "httpd",
 			}))
 		})
+
+		it("writes the launch config to a layer of its own", func() {
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				Layers:     packit.Layers{Path: layersDir},
+				CNBPath:    cnbPath,
+				Stack:      "some-stack",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(2))
+			layer := result.Layers[1]
+
+			Expect(layer.Name).To(Equal("launch-config"))
+			Expect(layer.Path).To(Equal(filepath.Join(layersDir, "launch-config")))
+			Expect(layer.Build).To(BeFalse())
+			Expect(layer.Cache).To(BeFalse())
+			Expect(layer.Launch).To(BeTrue())
+			Expect(layer.ExecD).To(Equal([]string{"tune-mpm", "render-config"}))
+
+			content, err := os.ReadFile(filepath.Join(layer.Path, "build-environment.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring(`"WebServer":"httpd"`))
+		})
+
+		it("records the files from service bindings with the build environment", func() {
+			bindingResolver.ResolveCall.Stub = func(typ, _, _ string) ([]servicebindings.Binding, error) {
+				if typ != "htpasswd" {
+					return nil, nil
+				}
+
+				return []servicebindings.Binding{
+					{
+						Name: "some-binding",
+						Type: "htpasswd",
+						Path: "some-binding-path",
+						Entries: map[string]*servicebindings.Entry{
+							".htpasswd": servicebindings.NewEntry("some-path"),
+						},
+					},
+				}, nil
+			}
+
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				Layers:     packit.Layers{Path: layersDir},
+				CNBPath:    cnbPath,
+				Stack:      "some-stack",
+				Platform:   packit.Platform{Path: "platform"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(bindingResolver.ResolveCall.Receives.PlatformDir).To(Equal("platform"))
+			Expect(generateConfig.GenerateCall.Receives.BuildEnvironment.BindingsResolved).To(BeTrue())
+			Expect(generateConfig.GenerateCall.Receives.BuildEnvironment.BasicAuthFile).To(Equal("some-binding-path/.htpasswd"))
+
+			content, err := os.ReadFile(filepath.Join(layersDir, "launch-config", "build-environment.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring(`"BasicAuthFile":"some-binding-path/.htpasswd"`))
+			Expect(string(content)).To(ContainSubstring(`"BindingsResolved":true`))
+		})
+
+		it("writes the file served by the health check endpoint", func() {
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				Layers:     packit.Layers{Path: layersDir},
+				CNBPath:    cnbPath,
+				Stack:      "some-stack",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			content, err := os.ReadFile(filepath.Join(result.Layers[1].Path, "health.txt"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("ok\n"))
+		})
+
+		it("does not lint the generated httpd.conf", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				Layers:     packit.Layers{Path: layersDir},
+				CNBPath:    cnbPath,
+				Stack:      "some-stack",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(configLinter.LintCall.CallCount).To(Equal(0))
+			Expect(configChecker.CheckCall.CallCount).To(Equal(1))
+		})
+
+		it("does not precompress static assets", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				Layers:     packit.Layers{Path: layersDir},
+				CNBPath:    cnbPath,
+				Stack:      "some-stack",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(precompressor.PrecompressCall.CallCount).To(Equal(0))
+		})
+
+		context("when BP_WEB_SERVER_ENABLE_PRECOMPRESSION=true", func() {
+			it.Before(func() {
+				build = httpd.Build(
+					httpd.BuildEnvironment{
+						WebServer:                   "httpd",
+						WebServerPrecompressEnabled: true,
+						WebServerRoot:               "htdocs",
+					},
+					entryResolver,
+					dependencyService,
+					bindingResolver,
+					generateConfig,
+					generateRoadRunnerConfig,
+					precompressor,
+					configChecker,
+					configLinter,
+					sbomGenerator,
+					chronos.DefaultClock,
+					scribe.NewEmitter(buffer),
+				)
+			})
+
+			it("precompresses the static assets in the web server root", func() {
+				Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					Layers:     packit.Layers{Path: layersDir},
+					CNBPath:    cnbPath,
+					Stack:      "some-stack",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(precompressor.PrecompressCall.Receives.Root).To(Equal(filepath.Join(workingDir, "htdocs")))
+			})
+
+			context("when precompressing fails", func() {
+				it.Before(func() {
+					precompressor.PrecompressCall.Returns.Error = errors.New("failed to precompress")
+				})
+
+				it("returns an error", func() {
+					Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+					_, err := build(packit.BuildContext{
+						WorkingDir: workingDir,
+						Layers:     packit.Layers{Path: layersDir},
+						CNBPath:    cnbPath,
+					})
+					Expect(err).To(MatchError("failed to precompress"))
+				})
+			})
+		})
+
+		context("when BP_WEB_SERVER_ROOT is not set", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(workingDir, "public"), os.ModePerm)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(workingDir, "dist"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "dist", "index.html"), nil, 0600)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(workingDir, "build"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "build", "index.html"), nil, 0600)).To(Succeed())
+			})
+
+			it("selects the first known directory that contains an index.html", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					Layers:     packit.Layers{Path: layersDir},
+					CNBPath:    cnbPath,
+					Stack:      "some-stack",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(generateConfig.GenerateCall.Receives.BuildEnvironment).To(Equal(httpd.BuildEnvironment{
+					BindingsResolved:    true,
+					HealthCheckFilePath: filepath.Join(layersDir, "launch-config", "health.txt"),
+					WebServer:           "httpd",
+					WebServerRoot:       "dist",
+				}))
+
+				Expect(buffer.String()).To(ContainSubstring("Discovering web server root"))
+				Expect(buffer.String()).To(ContainSubstring("Selected 'dist', which contains an index.html"))
+			})
+
+			context("when no known directory contains an index.html", func() {
+				it.Before(func() {
+					Expect(os.RemoveAll(filepath.Join(workingDir, "dist"))).To(Succeed())
+					Expect(os.RemoveAll(filepath.Join(workingDir, "build"))).To(Succeed())
+				})
+
+				it("defaults to public", func() {
+					_, err := build(packit.BuildContext{
+						WorkingDir: workingDir,
+						Layers:     packit.Layers{Path: layersDir},
+						CNBPath:    cnbPath,
+						Stack:      "some-stack",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(generateConfig.GenerateCall.Receives.BuildEnvironment).To(Equal(httpd.BuildEnvironment{
+						BindingsResolved:    true,
+						HealthCheckFilePath: filepath.Join(layersDir, "launch-config", "health.txt"),
+						WebServer:           "httpd",
+					}))
+
+					Expect(buffer.String()).To(ContainSubstring("None of public, dist, build, _site, out contains an index.html, defaulting to 'public'"))
+				})
+			})
+
+			context("when public does not exist either", func() {
+				it.Before(func() {
+					Expect(os.RemoveAll(filepath.Join(workingDir, "public"))).To(Succeed())
+					Expect(os.RemoveAll(filepath.Join(workingDir, "dist"))).To(Succeed())
+					Expect(os.RemoveAll(filepath.Join(workingDir, "build"))).To(Succeed())
+				})
+
+				it("logs a warning", func() {
+					_, err := build(packit.BuildContext{
+						WorkingDir: workingDir,
+						Layers:     packit.Layers{Path: layersDir},
+						CNBPath:    cnbPath,
+						Stack:      "some-stack",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(buffer.String()).To(ContainSubstring("WARNING: none of public, dist, build, _site, out contains an index.html and the default 'public' does not exist"))
+				})
+			})
+		})
+
+		context("when BP_WEB_SERVER_ROOT is set", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(workingDir, "dist"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "dist", "index.html"), nil, 0600)).To(Succeed())
+
+				build = httpd.Build(
+					httpd.BuildEnvironment{
+						WebServer:     "httpd",
+						WebServerRoot: "htdocs",
+					},
+					entryResolver,
+					dependencyService,
+					bindingResolver,
+					generateConfig,
+					generateRoadRunnerConfig,
+					precompressor,
+					configChecker,
+					configLinter,
+					sbomGenerator,
+					chronos.DefaultClock,
+					scribe.NewEmitter(buffer),
+				)
+			})
+
+			it("uses the configured root without discovery", func() {
+				Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					Layers:     packit.Layers{Path: layersDir},
+					CNBPath:    cnbPath,
+					Stack:      "some-stack",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(generateConfig.GenerateCall.Receives.BuildEnvironment.WebServerRoot).To(Equal("htdocs"))
+				Expect(buffer.String()).NotTo(ContainSubstring("Discovering web server root"))
+			})
+
+			context("when the configured root does not exist", func() {
+				it("returns an error", func() {
+					_, err := build(packit.BuildContext{
+						WorkingDir: workingDir,
+						Layers:     packit.Layers{Path: layersDir},
+						CNBPath:    cnbPath,
+					})
+					Expect(err).To(MatchError(`failed: BP_WEB_SERVER_ROOT "htdocs" does not exist: set it to the directory that contains the files to serve, relative to the application root`))
+					Expect(generateConfig.GenerateCall.CallCount).To(Equal(0))
+				})
+			})
+
+			context("when the configured root is a file", func() {
+				it("returns an error", func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "htdocs"), nil, 0600)).To(Succeed())
+
+					_, err := build(packit.BuildContext{
+						WorkingDir: workingDir,
+						Layers:     packit.Layers{Path: layersDir},
+						CNBPath:    cnbPath,
+					})
+					Expect(err).To(MatchError(`failed: BP_WEB_SERVER_ROOT "htdocs" is not a directory`))
+				})
+			})
+		})
+	})
+
+	context("when the plan requires roadrunner", func() {
+		it.Before(func() {
+			entryResolver.ResolveCall.Returns.BuildpackPlanEntry = packit.BuildpackPlanEntry{
+				Name: "roadrunner",
+				Metadata: map[string]interface{}{
+					"version-source": "BP_ROADRUNNER_VERSION",
+					"version":        "some-rr-version",
+					"launch":         true,
+				},
+			}
+
+			dependencyService.ResolveCall.Returns.Dependency = postal.Dependency{
+				ID:      "roadrunner",
+				SHA256:  "some-rr-sha", //nolint:staticcheck
+				Source:  "some-source",
+				Stacks:  []string{"some-stack"},
+				URI:     "some-uri",
+				Version: "some-rr-version",
+			}
+		})
+
+		it("builds roadrunner", func() {
+			result, err := build(packit.BuildContext{
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "1.2.3",
+				},
+				WorkingDir: workingDir,
+				Layers:     packit.Layers{Path: layersDir},
+				CNBPath:    cnbPath,
+				Stack:      "some-stack",
+				Platform:   packit.Platform{Path: "platform"},
+				Plan: packit.BuildpackPlan{
+					Entries: []packit.BuildpackPlanEntry{
+						{
+							Name: "roadrunner",
+							Metadata: map[string]interface{}{
+								"version-source": "BP_ROADRUNNER_VERSION",
+								"version":        "some-rr-version",
+								"launch":         true,
+							},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(1))
+			layer := result.Layers[0]
+
+			Expect(layer.Name).To(Equal("roadrunner"))
+			Expect(layer.Path).To(Equal(filepath.Join(layersDir, "roadrunner")))
+			Expect(layer.Launch).To(BeTrue())
+			Expect(layer.LaunchEnv).To(Equal(packit.Environment{
+				"APP_ROOT.override": workingDir,
+			}))
+			Expect(layer.Metadata).To(Equal(map[string]interface{}{
+				"cache_sha": "some-rr-sha",
+			}))
+
+			Expect(result.Launch.Processes).To(Equal([]packit.Process{
+				{
+					Type:    "web",
+					Command: "rr",
+					Args: []string{
+						"serve",
+						"-c",
+						filepath.Join(workingDir, ".rr.yaml"),
+						"-w",
+						workingDir,
+					},
+					Default: true,
+					Direct:  true,
+				},
+			}))
+
+			Expect(entryResolver.ResolveCall.Receives.Name).To(Equal("roadrunner"))
+			Expect(entryResolver.ResolveCall.Receives.Priorites).To(Equal([]interface{}{"BP_ROADRUNNER_VERSION", "composer.lock"}))
+
+			Expect(dependencyService.ResolveCall.Receives.Path).To(Equal(filepath.Join(cnbPath, "buildpack.toml")))
+			Expect(dependencyService.ResolveCall.Receives.Name).To(Equal("roadrunner"))
+			Expect(dependencyService.ResolveCall.Receives.Version).To(Equal("some-rr-version"))
+			Expect(dependencyService.ResolveCall.Receives.Stack).To(Equal("some-stack"))
+
+			Expect(dependencyService.DeliverCall.Receives.Dependency.ID).To(Equal("roadrunner"))
+			Expect(dependencyService.DeliverCall.Receives.CnbPath).To(Equal(cnbPath))
+			Expect(dependencyService.DeliverCall.Receives.LayerPath).To(Equal(filepath.Join(layersDir, "roadrunner", "bin")))
+			Expect(dependencyService.DeliverCall.Receives.PlatformPath).To(Equal("platform"))
+
+			Expect(generateConfig.GenerateCall.CallCount).To(Equal(0))
+			Expect(generateRoadRunnerConfig.GenerateCall.Receives.WorkingDir).To(Equal(workingDir))
+			Expect(generateRoadRunnerConfig.GenerateCall.Receives.PlatformPath).To(Equal("platform"))
+
+			Expect(sbomGenerator.GenerateFromDependencyCall.Receives.Dir).To(Equal(filepath.Join(layersDir, "roadrunner")))
+
+			Expect(buffer.String()).To(ContainSubstring("Resolving RoadRunner version"))
+			Expect(buffer.String()).To(ContainSubstring("Installing RoadRunner some-rr-version"))
+		})
+
+		context("when the buildpack.toml lists roadrunner in the format of the dependency retrieval", func() {
+			var platformDir string
+
+			it.Before(func() {
+				var err error
+				platformDir, err = os.MkdirTemp("", "platform")
+				Expect(err).NotTo(HaveOccurred())
+
+				archive := bytes.NewBuffer(nil)
+				gzipWriter := gzip.NewWriter(archive)
+				tarWriter := tar.NewWriter(gzipWriter)
+				for _, file := range []string{"LICENSE", "rr"} {
+					Expect(tarWriter.WriteHeader(&tar.Header{
+						Name: filepath.Join("roadrunner-2023.3.7-linux-amd64", file),
+						Mode: 0755,
+						Size: int64(len(file)),
+					})).To(Succeed())
+					_, err = tarWriter.Write([]byte(file))
+					Expect(err).NotTo(HaveOccurred())
+				}
+				Expect(tarWriter.Close()).To(Succeed())
+				Expect(gzipWriter.Close()).To(Succeed())
+
+				Expect(os.MkdirAll(filepath.Join(cnbPath, "dependencies"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(cnbPath, "dependencies", "roadrunner-2023.3.7-linux-amd64.tar.gz"), archive.Bytes(), 0600)).To(Succeed())
+
+				var dependencies string
+				for _, stack := range []string{"io.buildpacks.stacks.bionic", "io.buildpacks.stacks.jammy"} {
+					dependencies += fmt.Sprintf(`
+  [[metadata.dependencies]]
+    checksum = "sha256:%x"
+    cpe = "cpe:2.3:a:spiralscout:roadrunner:2023.3.7:*:*:*:*:*:*:*"
+    id = "roadrunner"
+    licenses = ["MIT"]
+    name = "RoadRunner"
+    purl = "pkg:generic/roadrunner@2023.3.7"
+    source = "https://github.com/roadrunner-server/roadrunner/archive/refs/tags/v2023.3.7.tar.gz"
+    source-checksum = "sha256:some-source-sha"
+    stacks = ["%s"]
+    strip-components = 1
+    uri = "file://dependencies/roadrunner-2023.3.7-linux-amd64.tar.gz"
+    version = "2023.3.7"
+`, sha256.Sum256(archive.Bytes()), stack)
+				}
+
+				Expect(os.WriteFile(filepath.Join(cnbPath, "buildpack.toml"), []byte(`api = "0.7"
+
+[buildpack]
+  id = "paketo-buildpacks/httpd"
+
+[metadata]
+`+dependencies+`
+  [[metadata.dependency-constraints]]
+    constraint = "*"
+    id = "roadrunner"
+    patches = 2
+`), 0600)).To(Succeed())
+
+				entryResolver.ResolveCall.Returns.BuildpackPlanEntry = packit.BuildpackPlanEntry{
+					Name: "roadrunner",
+					Metadata: map[string]interface{}{
+						"launch": true,
+					},
+				}
+
+				build = httpd.Build(
+					httpd.BuildEnvironment{},
+					entryResolver,
+					postal.NewService(cargo.NewTransport()),
+					bindingResolver,
+					generateConfig,
+					generateRoadRunnerConfig,
+					precompressor,
+					configChecker,
+					configLinter,
+					sbomGenerator,
+					chronos.DefaultClock,
+					scribe.NewEmitter(buffer),
+				)
+			})
+
+			it.After(func() {
+				Expect(os.RemoveAll(platformDir)).To(Succeed())
+			})
+
+			it("installs the rr binary into the bin directory of the layer on every stack", func() {
+				for _, stack := range []string{"io.buildpacks.stacks.bionic", "io.buildpacks.stacks.jammy"} {
+					result, err := build(packit.BuildContext{
+						WorkingDir: workingDir,
+						Layers:     packit.Layers{Path: layersDir},
+						CNBPath:    cnbPath,
+						Stack:      stack,
+						Platform:   packit.Platform{Path: platformDir},
+						Plan: packit.BuildpackPlan{
+							Entries: []packit.BuildpackPlanEntry{
+								{Name: "roadrunner"},
+							},
+						},
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(result.Layers).To(HaveLen(1))
+					Expect(result.Layers[0].Name).To(Equal("roadrunner"))
+					Expect(result.Layers[0].Metadata).To(HaveKey("cache_sha"))
+
+					Expect(filepath.Join(layersDir, "roadrunner", "bin", "rr")).To(BeARegularFile())
+
+					Expect(os.RemoveAll(filepath.Join(layersDir, "roadrunner.toml"))).To(Succeed())
+				}
+			})
+		})
+
+		context("when there is a .rr.yaml in the workspace", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, ".rr.yaml"), nil, 0600)).To(Succeed())
+			})
+
+			it("does not generate a .rr.yaml", func() {
+				_, err := build(packit.BuildContext{
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "1.2.3",
+					},
+					WorkingDir: workingDir,
+					Layers:     packit.Layers{Path: layersDir},
+					CNBPath:    cnbPath,
+					Stack:      "some-stack",
+					Plan: packit.BuildpackPlan{
+						Entries: []packit.BuildpackPlanEntry{
+							{Name: "roadrunner"},
+						},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(generateRoadRunnerConfig.GenerateCall.CallCount).To(Equal(0))
+			})
+		})
+
+		context("when the layer metadata contains a cache match", func() {
+			it.Before(func() {
+				err := os.WriteFile(filepath.Join(layersDir, "roadrunner.toml"),
+					[]byte("[metadata]\ncache_sha = \"some-rr-sha\"\n"), 0600)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			it("reuses the layer", func() {
+				result, err := build(packit.BuildContext{
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "1.2.3",
+					},
+					WorkingDir: workingDir,
+					Layers:     packit.Layers{Path: layersDir},
+					CNBPath:    cnbPath,
+					Stack:      "some-stack",
+					Plan: packit.BuildpackPlan{
+						Entries: []packit.BuildpackPlanEntry{
+							{Name: "roadrunner"},
+						},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Layers).To(HaveLen(1))
+				Expect(result.Layers[0].Name).To(Equal("roadrunner"))
+				Expect(result.Layers[0].Launch).To(BeTrue())
+
+				Expect(dependencyService.DeliverCall.CallCount).To(Equal(0))
+			})
+		})
+
+		context("when another buildpack requires httpd", func() {
+			var buildContext packit.BuildContext
+
+			it.Before(func() {
+				buildContext = packit.BuildContext{
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "1.2.3",
+					},
+					WorkingDir: workingDir,
+					Layers:     packit.Layers{Path: layersDir},
+					CNBPath:    cnbPath,
+					Stack:      "some-stack",
+					Plan: packit.BuildpackPlan{
+						Entries: []packit.BuildpackPlanEntry{
+							{Name: "roadrunner"},
+							{Name: "httpd"},
+						},
+					},
+				}
+			})
+
+			it("builds httpd instead of the roadrunner detected from the composer files", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Layers[0].Name).To(Equal("httpd"))
+
+				Expect(entryResolver.ResolveCall.Receives.Name).To(Equal("httpd"))
+				Expect(dependencyService.ResolveCall.Receives.Name).To(Equal("httpd"))
+				Expect(generateRoadRunnerConfig.GenerateCall.CallCount).To(Equal(0))
+			})
+
+			context("when BP_WEB_SERVER=roadrunner", func() {
+				it.Before(func() {
+					build = httpd.Build(
+						httpd.BuildEnvironment{WebServer: "roadrunner"},
+						entryResolver,
+						dependencyService,
+						bindingResolver,
+						generateConfig,
+						generateRoadRunnerConfig,
+						precompressor,
+						configChecker,
+						configLinter,
+						sbomGenerator,
+						chronos.DefaultClock,
+						scribe.NewEmitter(buffer),
+					)
+				})
+
+				it("builds roadrunner", func() {
+					result, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(result.Layers).To(HaveLen(1))
+					Expect(result.Layers[0].Name).To(Equal("roadrunner"))
+
+					Expect(entryResolver.ResolveCall.Receives.Name).To(Equal("roadrunner"))
+					Expect(dependencyService.ResolveCall.Receives.Name).To(Equal("roadrunner"))
+				})
+			})
+		})
+
+		context("when BP_LIVE_RELOAD_ENABLED=true in the build environment", func() {
+			it.Before(func() {
+				build = httpd.Build(
+					httpd.BuildEnvironment{
+						Reload: true,
+					},
+					entryResolver,
+					dependencyService,
+					bindingResolver,
+					generateConfig,
+					generateRoadRunnerConfig,
+					precompressor,
+					configChecker,
+					configLinter,
+					sbomGenerator,
+					chronos.DefaultClock,
+					scribe.NewEmitter(buffer),
+				)
+			})
+
+			it("uses watchexec to set the start command", func() {
+				result, err := build(packit.BuildContext{
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "1.2.3",
+					},
+					WorkingDir: workingDir,
+					Layers:     packit.Layers{Path: layersDir},
+					CNBPath:    cnbPath,
+					Stack:      "some-stack",
+					Plan: packit.BuildpackPlan{
+						Entries: []packit.BuildpackPlanEntry{
+							{Name: "roadrunner"},
+						},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Launch.Processes).To(Equal([]packit.Process{
+					{
+						Type:    "web",
+						Command: "watchexec",
+						Args: []string{
+							"--restart",
+							"--watch", workingDir,
+							"--shell", "none",
+							"--",
+							"rr",
+							"serve",
+							"-c",
+							filepath.Join(workingDir, ".rr.yaml"),
+							"-w",
+							workingDir,
+						},
+						Default: true,
+						Direct:  true,
+					},
+					{
+						Type:    "no-reload",
+						Command: "rr",
+						Args: []string{
+							"serve",
+							"-c",
+							filepath.Join(workingDir, ".rr.yaml"),
+							"-w",
+							workingDir,
+						},
+						Direct: true,
+					},
+				}))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the dependency cannot be resolved", func() {
+				it.Before(func() {
+					dependencyService.ResolveCall.Returns.Error = errors.New("failed to resolve dependency")
+				})
+
+				it("returns an error", func() {
+					_, err := build(packit.BuildContext{
+						WorkingDir: workingDir,
+						Layers:     packit.Layers{Path: layersDir},
+						CNBPath:    cnbPath,
+						Plan: packit.BuildpackPlan{
+							Entries: []packit.BuildpackPlanEntry{
+								{Name: "roadrunner"},
+							},
+						},
+					})
+					Expect(err).To(MatchError("failed to resolve dependency"))
+				})
+			})
+
+			context("when generating the .rr.yaml fails", func() {
+				it.Before(func() {
+					generateRoadRunnerConfig.GenerateCall.Returns.Error = errors.New("failed to generate config file")
+				})
+
+				it("returns an error", func() {
+					_, err := build(packit.BuildContext{
+						WorkingDir: workingDir,
+						Layers:     packit.Layers{Path: layersDir},
+						CNBPath:    cnbPath,
+						Plan: packit.BuildpackPlan{
+							Entries: []packit.BuildpackPlanEntry{
+								{Name: "roadrunner"},
+							},
+						},
+					})
+					Expect(err).To(MatchError("failed to generate config file"))
+				})
+			})
+
+			context("when the dependency cannot be installed", func() {
+				it.Before(func() {
+					dependencyService.DeliverCall.Returns.Error = errors.New("failed to install dependency")
+				})
+
+				it("returns an error", func() {
+					_, err := build(packit.BuildContext{
+						WorkingDir: workingDir,
+						Layers:     packit.Layers{Path: layersDir},
+						CNBPath:    cnbPath,
+						Plan: packit.BuildpackPlan{
+							Entries: []packit.BuildpackPlanEntry{
+								{Name: "roadrunner"},
+							},
+						},
+					})
+					Expect(err).To(MatchError("failed to install dependency"))
+				})
+			})
+		})
 	})
 
 	context("when the layer metadata contains a cache match", func() {
@@ -471,6 +1279,9 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 				[]byte("[metadata]\ncache_sha = \"some-sha\"\n"), 0600)
 			Expect(err).NotTo(HaveOccurred())
 
+			Expect(os.MkdirAll(filepath.Join(layersDir, "httpd", "bin"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(layersDir, "httpd", "bin", "httpd"), nil, 0700)).To(Succeed())
+
 			entryResolver.MergeLayerTypesCall.Returns.Launch = true
 		})
 
@@ -499,13 +1310,13 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			})
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(result.Layers).To(HaveLen(1))
+			Expect(result.Layers).To(HaveLen(2))
 			layer := result.Layers[0]
 
 			Expect(layer.Name).To(Equal("httpd"))
 			Expect(layer.Path).To(Equal(filepath.Join(layersDir, "httpd")))
 			Expect(layer.Build).To(BeFalse())
-			Expect(layer.Cache).To(BeFalse())
+			Expect(layer.Cache).To(BeTrue())
 			Expect(layer.Launch).To(BeTrue())
 
 			Expect(result.Launch.BOM).To(Equal([]packit.BOMEntry{
@@ -539,6 +1350,139 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			}))
 
 			Expect(dependencyService.DeliverCall.CallCount).To(Equal(0))
+
+			Expect(configLinter.LintCall.CallCount).To(Equal(1))
+			Expect(configLinter.LintCall.Receives.ServerRoot).To(Equal(filepath.Join(layersDir, "httpd")))
+			Expect(configLinter.LintCall.Receives.AppRoot).To(Equal(workingDir))
+			Expect(configLinter.LintCall.Receives.ConfigPath).To(Equal(filepath.Join(workingDir, "httpd.conf")))
+			Expect(configLinter.LintCall.Receives.Strict).To(BeFalse())
+
+			Expect(configChecker.CheckCall.CallCount).To(Equal(1))
+			Expect(configChecker.CheckCall.Receives.ServerRoot).To(Equal(filepath.Join(layersDir, "httpd")))
+			Expect(configChecker.CheckCall.Receives.AppRoot).To(Equal(workingDir))
+			Expect(configChecker.CheckCall.Receives.ConfigPath).To(Equal(filepath.Join(workingDir, "httpd.conf")))
+
+			Expect(result.Layers[1].Name).To(Equal("launch-config"))
+			Expect(result.Layers[1].ExecD).To(Equal([]string{"tune-mpm"}))
+			Expect(filepath.Join(layersDir, "httpd", "build-environment.json")).NotTo(BeAnExistingFile())
+		})
+
+		context("when the cached layer was restored without its contents", func() {
+			it.Before(func() {
+				Expect(os.RemoveAll(filepath.Join(layersDir, "httpd"))).To(Succeed())
+			})
+
+			it("installs httpd again and checks the config", func() {
+				result, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					Layers:     packit.Layers{Path: layersDir},
+					CNBPath:    cnbPath,
+					Stack:      "some-stack",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Layers[0].Name).To(Equal("httpd"))
+				Expect(result.Layers[0].Cache).To(BeTrue())
+
+				Expect(dependencyService.DeliverCall.CallCount).To(Equal(1))
+				Expect(configChecker.CheckCall.CallCount).To(Equal(1))
+			})
+		})
+
+		context("when linting the httpd.conf fails in strict mode", func() {
+			it.Before(func() {
+				configLinter.LintCall.Returns.Error = errors.New("failed: httpd.conf has 1 lint warning")
+			})
+
+			it("fails the build", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					Layers:     packit.Layers{Path: layersDir},
+					CNBPath:    cnbPath,
+					Stack:      "some-stack",
+				})
+				Expect(err).To(MatchError("failed: httpd.conf has 1 lint warning"))
+
+				Expect(configChecker.CheckCall.CallCount).To(Equal(0))
+			})
+		})
+
+		context("when the httpd.conf is not valid", func() {
+			it.Before(func() {
+				configChecker.CheckCall.Returns.Error = errors.New("failed: httpd.conf is not valid")
+			})
+
+			it("fails the build", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					Layers:     packit.Layers{Path: layersDir},
+					CNBPath:    cnbPath,
+					Stack:      "some-stack",
+				})
+				Expect(err).To(MatchError("failed: httpd.conf is not valid"))
+			})
+		})
+
+		context("when BP_WEB_SERVER=httpd", func() {
+			it.Before(func() {
+				build = httpd.Build(
+					httpd.BuildEnvironment{
+						WebServer: "httpd",
+					},
+					entryResolver,
+					dependencyService,
+					bindingResolver,
+					generateConfig,
+					generateRoadRunnerConfig,
+					precompressor,
+					configChecker,
+					configLinter,
+					sbomGenerator,
+					chronos.DefaultClock,
+					scribe.NewEmitter(buffer),
+				)
+			})
+
+			it("writes the launch config to a layer of its own", func() {
+				result, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					Layers:     packit.Layers{Path: layersDir},
+					CNBPath:    cnbPath,
+					Stack:      "some-stack",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Layers).To(HaveLen(2))
+				Expect(result.Layers[0].Name).To(Equal("httpd"))
+				Expect(result.Layers[1].Name).To(Equal("launch-config"))
+				Expect(result.Layers[1].ExecD).To(Equal([]string{"tune-mpm", "render-config"}))
+
+				Expect(filepath.Join(layersDir, "httpd", "build-environment.json")).NotTo(BeAnExistingFile())
+				Expect(filepath.Join(layersDir, "httpd", "health.txt")).NotTo(BeAnExistingFile())
+				Expect(filepath.Join(layersDir, "launch-config", "build-environment.json")).To(BeAnExistingFile())
+				Expect(filepath.Join(layersDir, "launch-config", "health.txt")).To(BeAnExistingFile())
+			})
+		})
+	})
+
+	context("when the app does not contain a httpd.conf", func() {
+		it.Before(func() {
+			Expect(os.Remove(filepath.Join(workingDir, "httpd.conf"))).To(Succeed())
+		})
+
+		it("installs httpd without linting or checking the config", func() {
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				Layers:     packit.Layers{Path: layersDir},
+				CNBPath:    cnbPath,
+				Stack:      "some-stack",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(2))
+			Expect(dependencyService.DeliverCall.CallCount).To(Equal(1))
+			Expect(configLinter.LintCall.CallCount).To(Equal(0))
+			Expect(configChecker.CheckCall.CallCount).To(Equal(0))
 		})
 	})
 
@@ -550,7 +1494,12 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 				},
 				entryResolver,
 				dependencyService,
+				bindingResolver,
 				generateConfig,
+				generateRoadRunnerConfig,
+				precompressor,
+				configChecker,
+				configLinter,
 				sbomGenerator,
 				chronos.DefaultClock,
 				scribe.NewEmitter(buffer),
@@ -657,7 +1606,12 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 					},
 					entryResolver,
 					dependencyService,
+					bindingResolver,
 					generateConfig,
+					generateRoadRunnerConfig,
+					precompressor,
+					configChecker,
+					configLinter,
 					sbomGenerator,
 					chronos.DefaultClock,
 					scribe.NewEmitter(buffer),
@@ -689,6 +1643,36 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("when the config check fails", func() {
+			it.Before(func() {
+				configChecker.CheckCall.Returns.Error = errors.New("failed to check config")
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					Layers:     packit.Layers{Path: layersDir},
+					CNBPath:    cnbPath,
+				})
+				Expect(err).To(MatchError("failed to check config"))
+			})
+		})
+
+		context("when linting the config fails", func() {
+			it.Before(func() {
+				configLinter.LintCall.Returns.Error = errors.New("failed to lint config")
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					Layers:     packit.Layers{Path: layersDir},
+					CNBPath:    cnbPath,
+				})
+				Expect(err).To(MatchError("failed to lint config"))
+			})
+		})
+
 		context("when generating the SBOM returns an error", func() {
 			it.Before(func() {
 				sbomGenerator.GenerateFromDependencyCall.Returns.Error = errors.New("failed to generate SBOM")
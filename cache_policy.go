@@ -0,0 +1,144 @@
+package httpd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// CachePolicy is a Cache-Control value applied to the requests that match a
+// URL path or file name pattern.
+type CachePolicy struct {
+	Section      string
+	Pattern      string
+	CacheControl string
+	MaxAge       int
+}
+
+var maxAgeRegexp = regexp.MustCompile(`(?:^|,)\s*max-age=(\d+)\s*(?:,|$)`)
+
+// parseCachePolicies reads the cache rules from web-server.toml followed by
+// the rules in spec. Rules in spec take the form
+// "<pattern>[,<pattern>]=<cache-control>" and are separated by semicolons.
+func parseCachePolicies(webServerTOMLPath, spec string) ([]CachePolicy, error) {
+	var config struct {
+		Cache []struct {
+			Match        string `toml:"match"`
+			CacheControl string `toml:"cache-control"`
+		} `toml:"cache"`
+	}
+	_, err := toml.DecodeFile(webServerTOMLPath, &config)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to parse web-server.toml: %w", err)
+	}
+
+	var policies []CachePolicy
+	for _, rule := range config.Cache {
+		p, err := newCachePolicies(rule.Match, rule.CacheControl)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p...)
+	}
+
+	for _, rule := range strings.Split(spec, ";") {
+		if strings.TrimSpace(rule) == "" {
+			continue
+		}
+
+		match, cacheControl, ok := strings.Cut(rule, "=")
+		if !ok {
+			return nil, fmt.Errorf("failed to parse cache policy %q: expected <pattern>=<cache-control>", rule)
+		}
+
+		p, err := newCachePolicies(match, cacheControl)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p...)
+	}
+
+	return policies, nil
+}
+
+func newCachePolicies(match, cacheControl string) ([]CachePolicy, error) {
+	cacheControl = strings.TrimSpace(cacheControl)
+	if cacheControl == "" || validateConfigValue("cache-control", cacheControl) != nil {
+		return nil, fmt.Errorf("failed to parse cache policy for %q: invalid cache-control %q", match, cacheControl)
+	}
+
+	var maxAge int
+	if matches := maxAgeRegexp.FindStringSubmatch(cacheControl); matches != nil {
+		maxAge, _ = strconv.Atoi(matches[1])
+	}
+
+	var paths, files []string
+	for _, pattern := range strings.Split(match, ",") {
+		pattern = strings.TrimSpace(pattern)
+
+		err := validateConfigValue("cache policy pattern", pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case pattern == "":
+			continue
+		case strings.HasPrefix(pattern, "/"):
+			paths = append(paths, globToRegexp(pattern, ".*"))
+		case strings.Contains(pattern, "/"):
+			return nil, fmt.Errorf("failed to parse cache policy for %q: file name pattern %q must not contain '/'", match, pattern)
+		default:
+			files = append(files, globToRegexp(pattern, "[^/]*"))
+		}
+	}
+
+	if len(paths) == 0 && len(files) == 0 {
+		return nil, fmt.Errorf("failed to parse cache policy for %q: no pattern given", match)
+	}
+
+	var policies []CachePolicy
+	if len(files) > 0 {
+		policies = append(policies, CachePolicy{
+			Section:      "FilesMatch",
+			Pattern:      fmt.Sprintf("^(%s)$", strings.Join(files, "|")),
+			CacheControl: cacheControl,
+			MaxAge:       maxAge,
+		})
+	}
+
+	if len(paths) > 0 {
+		policies = append(policies, CachePolicy{
+			Section:      "LocationMatch",
+			Pattern:      fmt.Sprintf("^(%s)$", strings.Join(paths, "|")),
+			CacheControl: cacheControl,
+			MaxAge:       maxAge,
+		})
+	}
+
+	return policies, nil
+}
+
+// globToRegexp converts a glob pattern into a regular expression, where "*"
+// matches any run of characters allowed by star and "?" matches a single
+// character.
+func globToRegexp(glob, star string) string {
+	var builder strings.Builder
+	for _, r := range glob {
+		switch r {
+		case '*':
+			builder.WriteString(star)
+		case '?':
+			builder.WriteString("[^/]")
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/paketo-buildpacks/httpd"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
+)
+
+// render-config runs from the exec.d directory of the launch config layer when
+// the container starts and renders the zero-config httpd.conf again when the
+// launch environment sets any WEB_SERVER_* variable.
+func main() {
+	logEmitter := scribe.NewEmitter(os.Stdout)
+	generateHTTPDConfig := httpd.NewGenerateHTTPDConfig(servicebindings.NewResolver(), logEmitter)
+	renderer := httpd.NewLaunchConfigRenderer(generateHTTPDConfig, logEmitter)
+
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("failed to locate the launch config layer: %w", err))
+		os.Exit(1)
+	}
+
+	layerPath := filepath.Dir(filepath.Dir(executable))
+
+	err = renderer.Render(layerPath, os.Getenv("APP_ROOT"), "/platform", os.Environ())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("failed to render httpd.conf: %w", err))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/BurntSushi/toml"
+	"github.com/paketo-buildpacks/httpd"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// tune-mpm runs from the exec.d directory of the launch config layer when the
+// container starts and writes the event MPM settings sized for the cgroup
+// limits of the container to file descriptor 3 as launch environment
+// variables.
+func main() {
+	logEmitter := scribe.NewEmitter(os.Stdout)
+	tuner := httpd.NewMPMTuner("/sys/fs/cgroup", runtime.NumCPU())
+
+	settings, limits, err := tuner.Tune(os.Environ())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("failed to tune the event MPM: %w", err))
+		os.Exit(1)
+	}
+
+	memory := "unlimited memory"
+	if limits.Memory > 0 {
+		memory = fmt.Sprintf("%d MiB of memory", limits.Memory>>20)
+	}
+
+	logEmitter.Process("Sizing the event MPM for %d CPU(s) and %s", limits.CPUs, memory)
+	logEmitter.Subprocess("ServerLimit %d, ThreadsPerChild %d, MaxRequestWorkers %d, MaxConnectionsPerChild %d",
+		settings.ServerLimit, settings.ThreadsPerChild, settings.MaxRequestWorkers, settings.MaxConnectionsPerChild)
+
+	err = toml.NewEncoder(os.NewFile(3, "/dev/fd/3")).Encode(settings.Environment())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("failed to write launch environment: %w", err))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,91 @@
+package httpd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type ComposerDependencyParser struct{}
+
+func NewComposerDependencyParser() ComposerDependencyParser {
+	return ComposerDependencyParser{}
+}
+
+// ParseRoadRunner reports whether the composer files of the app in workingDir
+// require the RoadRunner application server, and the version of the rr binary
+// that composer.lock pins, if any. Only spiral/roadrunner shares its version
+// with the rr binary. spiral/roadrunner-http, spiral/roadrunner-cli and the
+// other spiral/roadrunner-* packages are PHP libraries that are versioned
+// independently and work with a range of rr releases, so their versions
+// select no binary and the latest rr is installed instead.
+func (c ComposerDependencyParser) ParseRoadRunner(workingDir string) (bool, string, string, error) {
+	var composerJSON struct {
+		Require map[string]string `json:"require"`
+	}
+	found, err := decodeJSONFile(filepath.Join(workingDir, "composer.json"), &composerJSON)
+	if err != nil {
+		return false, "", "", fmt.Errorf("failed to parse composer.json: %w", err)
+	}
+
+	if !found {
+		return false, "", "", nil
+	}
+
+	var composerLock struct {
+		Packages []struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"packages"`
+	}
+	_, err = decodeJSONFile(filepath.Join(workingDir, "composer.lock"), &composerLock)
+	if err != nil {
+		return false, "", "", fmt.Errorf("failed to parse composer.lock: %w", err)
+	}
+
+	packages := map[string]bool{}
+	for name := range composerJSON.Require {
+		packages[name] = true
+	}
+
+	var version string
+	for _, p := range composerLock.Packages {
+		packages[p.Name] = true
+
+		if p.Name == "spiral/roadrunner" {
+			version = strings.TrimPrefix(p.Version, "v")
+		}
+	}
+
+	octane := packages["laravel/octane"] && packages["spiral/roadrunner-cli"]
+	if !octane && !packages["spiral/roadrunner-http"] && !packages["spiral/roadrunner"] {
+		return false, "", "", nil
+	}
+
+	if version == "" {
+		return true, "", "", nil
+	}
+
+	return true, version, "composer.lock", nil
+}
+
+func decodeJSONFile(path string, v interface{}) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer file.Close()
+
+	err = json.NewDecoder(file).Decode(v)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,186 @@
+package httpd_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testComposerDependencyParser(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		workingDir string
+
+		composerParser httpd.ComposerDependencyParser
+	)
+
+	it.Before(func() {
+		var err error
+		workingDir, err = os.MkdirTemp("", "working-dir")
+		Expect(err).NotTo(HaveOccurred())
+
+		composerParser = httpd.NewComposerDependencyParser()
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	context("ParseRoadRunner", func() {
+		context("when there is no composer.json", func() {
+			it("does not require roadrunner", func() {
+				required, version, versionSource, err := composerParser.ParseRoadRunner(workingDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(required).To(BeFalse())
+				Expect(version).To(Equal(""))
+				Expect(versionSource).To(Equal(""))
+			})
+		})
+
+		context("when composer.json requires spiral/roadrunner-http", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "composer.json"), []byte(`{
+					"require": {
+						"php": "^8.1",
+						"spiral/roadrunner-http": "^3.0"
+					}
+				}`), 0600)).To(Succeed())
+			})
+
+			it("requires roadrunner without a version", func() {
+				required, version, versionSource, err := composerParser.ParseRoadRunner(workingDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(required).To(BeTrue())
+				Expect(version).To(Equal(""))
+				Expect(versionSource).To(Equal(""))
+			})
+
+			context("when composer.lock pins spiral/roadrunner", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "composer.lock"), []byte(`{
+						"packages": [
+							{"name": "spiral/roadrunner", "version": "v2023.3.7"},
+							{"name": "spiral/roadrunner-http", "version": "v3.2.0"}
+						]
+					}`), 0600)).To(Succeed())
+				})
+
+				it("returns the locked binary version", func() {
+					required, version, versionSource, err := composerParser.ParseRoadRunner(workingDir)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(required).To(BeTrue())
+					Expect(version).To(Equal("2023.3.7"))
+					Expect(versionSource).To(Equal("composer.lock"))
+				})
+			})
+		})
+
+		context("when composer.json requires laravel/octane", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "composer.json"), []byte(`{
+					"require": {
+						"laravel/octane": "^2.0"
+					}
+				}`), 0600)).To(Succeed())
+			})
+
+			it("does not require roadrunner without the roadrunner driver", func() {
+				required, _, _, err := composerParser.ParseRoadRunner(workingDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(required).To(BeFalse())
+			})
+
+			context("when the roadrunner driver is locked", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "composer.lock"), []byte(`{
+						"packages": [
+							{"name": "laravel/octane", "version": "v2.1.0"},
+							{"name": "spiral/roadrunner-cli", "version": "v2.5.0"}
+						]
+					}`), 0600)).To(Succeed())
+				})
+
+				it("requires roadrunner", func() {
+					required, version, versionSource, err := composerParser.ParseRoadRunner(workingDir)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(required).To(BeTrue())
+					Expect(version).To(Equal(""))
+					Expect(versionSource).To(Equal(""))
+				})
+			})
+
+			context("when the PHP libraries of roadrunner are locked", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "composer.lock"), []byte(`{
+						"packages": [
+							{"name": "laravel/octane", "version": "v2.1.0"},
+							{"name": "spiral/roadrunner-cli", "version": "v2.5.0"},
+							{"name": "spiral/roadrunner-http", "version": "v3.2.0"},
+							{"name": "spiral/roadrunner-worker", "version": "v3.1.0"}
+						]
+					}`), 0600)).To(Succeed())
+				})
+
+				it("does not take their versions for the rr binary, which is versioned independently", func() {
+					required, version, versionSource, err := composerParser.ParseRoadRunner(workingDir)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(required).To(BeTrue())
+					Expect(version).To(Equal(""))
+					Expect(versionSource).To(Equal(""))
+				})
+
+				context("when spiral/roadrunner is locked as well", func() {
+					it.Before(func() {
+						Expect(os.WriteFile(filepath.Join(workingDir, "composer.lock"), []byte(`{
+							"packages": [
+								{"name": "laravel/octane", "version": "v2.1.0"},
+								{"name": "spiral/roadrunner", "version": "v2023.3.7"},
+								{"name": "spiral/roadrunner-cli", "version": "v2.5.0"},
+								{"name": "spiral/roadrunner-http", "version": "v3.2.0"}
+							]
+						}`), 0600)).To(Succeed())
+					})
+
+					it("returns the version of spiral/roadrunner", func() {
+						required, version, versionSource, err := composerParser.ParseRoadRunner(workingDir)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(required).To(BeTrue())
+						Expect(version).To(Equal("2023.3.7"))
+						Expect(versionSource).To(Equal("composer.lock"))
+					})
+				})
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the composer.json is malformed", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "composer.json"), []byte("%%%"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, _, _, err := composerParser.ParseRoadRunner(workingDir)
+					Expect(err).To(MatchError(ContainSubstring("failed to parse composer.json")))
+				})
+			})
+
+			context("when the composer.lock is malformed", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "composer.json"), []byte("{}"), 0600)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(workingDir, "composer.lock"), []byte("%%%"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, _, _, err := composerParser.ParseRoadRunner(workingDir)
+					Expect(err).To(MatchError(ContainSubstring("failed to parse composer.lock")))
+				})
+			})
+		})
+	})
+}
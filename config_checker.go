@@ -0,0 +1,81 @@
+package httpd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// configCheckPort is the PORT used while checking the config. httpd -t does
+// not bind to the port, so any valid value will do.
+const configCheckPort = "8080"
+
+//go:generate faux --interface Executable --output fakes/executable.go
+type Executable interface {
+	Execute(execution pexec.Execution) error
+}
+
+type HTTPDConfigChecker struct {
+	executable Executable
+	logger     scribe.Emitter
+}
+
+func NewHTTPDConfigChecker(executable Executable, logger scribe.Emitter) HTTPDConfigChecker {
+	return HTTPDConfigChecker{
+		executable: executable,
+		logger:     logger,
+	}
+}
+
+// Check runs the httpd delivered to serverRoot against configPath with the
+// environment that the launch process will have. The MPM settings that
+// are computed at launch are set to the httpd defaults. The shared libraries
+// of the delivered httpd are found through LD_LIBRARY_PATH, as they are not
+// installed in the system library path.
+func (c HTTPDConfigChecker) Check(serverRoot, appRoot, configPath string) error {
+	c.logger.Process("Checking %s syntax", filepath.Base(configPath))
+
+	environment := append(os.Environ(),
+		fmt.Sprintf("PATH=%s%c%s", filepath.Join(serverRoot, "bin"), os.PathListSeparator, os.Getenv("PATH")),
+		fmt.Sprintf("LD_LIBRARY_PATH=%s", libraryPath(filepath.Join(serverRoot, "lib"), os.Getenv("LD_LIBRARY_PATH"))),
+		fmt.Sprintf("SERVER_ROOT=%s", serverRoot),
+		fmt.Sprintf("APP_ROOT=%s", appRoot),
+		fmt.Sprintf("PORT=%s", configCheckPort),
+	)
+
+	var mpmVariables []string
+	for name, value := range defaultMPMSettings.Environment() {
+		mpmVariables = append(mpmVariables, fmt.Sprintf("%s=%s", name, value))
+	}
+	sort.Strings(mpmVariables)
+
+	buffer := bytes.NewBuffer(nil)
+	err := c.executable.Execute(pexec.Execution{
+		Args:   []string{"-t", "-f", configPath},
+		Env:    append(environment, mpmVariables...),
+		Stdout: buffer,
+		Stderr: buffer,
+	})
+	if err != nil {
+		return fmt.Errorf("failed: %s is not valid: %w\n%s", configPath, err, strings.TrimSpace(buffer.String()))
+	}
+
+	c.logger.Action("%s", strings.TrimSpace(buffer.String()))
+	c.logger.Break()
+
+	return nil
+}
+
+func libraryPath(path, existing string) string {
+	if existing == "" {
+		return path
+	}
+
+	return fmt.Sprintf("%s%c%s", path, os.PathListSeparator, existing)
+}
@@ -0,0 +1,103 @@
+package httpd_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/paketo-buildpacks/httpd"
+	"github.com/paketo-buildpacks/httpd/fakes"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testHTTPDConfigChecker(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		executable *fakes.Executable
+		buffer     *bytes.Buffer
+
+		checker httpd.HTTPDConfigChecker
+
+		libraryPath string
+	)
+
+	it.Before(func() {
+		libraryPath = os.Getenv("LD_LIBRARY_PATH")
+		Expect(os.Unsetenv("LD_LIBRARY_PATH")).To(Succeed())
+
+		executable = &fakes.Executable{}
+		executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+			fmt.Fprintln(execution.Stderr, "Syntax OK")
+			return nil
+		}
+
+		buffer = bytes.NewBuffer(nil)
+		checker = httpd.NewHTTPDConfigChecker(executable, scribe.NewEmitter(buffer))
+	})
+
+	it.After(func() {
+		Expect(os.Setenv("LD_LIBRARY_PATH", libraryPath)).To(Succeed())
+	})
+
+	context("Check", func() {
+		it("runs httpd -t with the launch environment", func() {
+			err := checker.Check("/layers/httpd", "/workspace", "/workspace/httpd.conf")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executable.ExecuteCall.Receives.Execution.Args).To(Equal([]string{"-t", "-f", "/workspace/httpd.conf"}))
+			Expect(executable.ExecuteCall.Receives.Execution.Env).To(ContainElements(
+				fmt.Sprintf("PATH=/layers/httpd/bin:%s", os.Getenv("PATH")),
+				"LD_LIBRARY_PATH=/layers/httpd/lib",
+				"SERVER_ROOT=/layers/httpd",
+				"APP_ROOT=/workspace",
+				"PORT=8080",
+				"HTTPD_MAX_CONNECTIONS_PER_CHILD=0",
+				"HTTPD_MAX_REQUEST_WORKERS=400",
+				"HTTPD_SERVER_LIMIT=16",
+				"HTTPD_THREADS_PER_CHILD=25",
+			))
+
+			Expect(buffer.String()).To(ContainSubstring("Checking httpd.conf syntax"))
+			Expect(buffer.String()).To(ContainSubstring("Syntax OK"))
+		})
+
+		context("when LD_LIBRARY_PATH is set", func() {
+			it.Before(func() {
+				Expect(os.Setenv("LD_LIBRARY_PATH", "/some/lib")).To(Succeed())
+			})
+
+			it("prepends the httpd libraries", func() {
+				err := checker.Check("/layers/httpd", "/workspace", "/workspace/httpd.conf")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executable.ExecuteCall.Receives.Execution.Env).To(ContainElement("LD_LIBRARY_PATH=/layers/httpd/lib:/some/lib"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when httpd rejects the config", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						fmt.Fprintln(execution.Stderr, "AH00526: Syntax error on line 12 of /workspace/httpd.conf:")
+						fmt.Fprintln(execution.Stderr, "Invalid command 'Foo', perhaps misspelled or defined by a module not included in the server configuration")
+						return errors.New("exit status 1")
+					}
+				})
+
+				it("returns an error containing the httpd output", func() {
+					err := checker.Check("/layers/httpd", "/workspace", "/workspace/httpd.conf")
+					Expect(err).To(MatchError(`failed: /workspace/httpd.conf is not valid: exit status 1
+AH00526: Syntax error on line 12 of /workspace/httpd.conf:
+Invalid command 'Foo', perhaps misspelled or defined by a module not included in the server configuration`))
+				})
+			})
+		})
+	})
+}
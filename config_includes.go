@@ -0,0 +1,74 @@
+package httpd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
+)
+
+// ConfigSnippetsDir is the directory in the application whose *.conf files
+// are included in the generated httpd.conf.
+const ConfigSnippetsDir = "httpd.d"
+
+// configIncludes returns the paths of the config snippets to include in the
+// generated httpd.conf. Snippets from the ConfigSnippetsDir of the application
+// come first, sorted by file name, followed by the *.conf entries of the
+// httpd-config bindings, sorted by binding name and then by entry name.
+func configIncludes(workingDir string, bindings []servicebindings.Binding) ([]string, error) {
+	var includes []string
+
+	entries, err := os.ReadDir(filepath.Join(workingDir, ConfigSnippetsDir))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".conf" {
+			continue
+		}
+
+		err = validateConfigValue(fmt.Sprintf("config snippet '%s'", entry.Name()), entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		includes = append(includes, fmt.Sprintf("${APP_ROOT}/%s/%s", ConfigSnippetsDir, entry.Name()))
+	}
+
+	sort.Slice(bindings, func(i, j int) bool {
+		return bindings[i].Name < bindings[j].Name
+	})
+
+	for _, binding := range bindings {
+		var names []string
+		for name := range binding.Entries {
+			if strings.HasSuffix(name, ".conf") {
+				names = append(names, name)
+			}
+		}
+
+		if len(names) == 0 {
+			return nil, fmt.Errorf("failed: binding '%s' of type 'httpd-config' does not contain any *.conf entries", binding.Name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			path := filepath.Join(binding.Path, name)
+
+			err = validateConfigValue(fmt.Sprintf("entry of binding '%s'", binding.Name), path)
+			if err != nil {
+				return nil, err
+			}
+
+			includes = append(includes, path)
+		}
+	}
+
+	return includes, nil
+}
@@ -0,0 +1,178 @@
+package httpd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/httpd/apacheconf"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// launchVariables are set in the launch environment by this buildpack.
+var launchVariables = map[string]bool{
+	"APP_ROOT":                     true,
+	"PORT":                         true,
+	"SERVER_ROOT":                  true,
+	ServerLimitVariable:            true,
+	ThreadsPerChildVariable:        true,
+	MaxRequestWorkersVariable:      true,
+	MaxConnectionsPerChildVariable: true,
+}
+
+// writablePrefixes are the paths that the launch user can write to.
+var writablePrefixes = []string{"/tmp/", "/dev/shm/"}
+
+// ConfigLintFinding is a container pitfall found in an httpd.conf.
+type ConfigLintFinding struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (f ConfigLintFinding) String() string {
+	if f.File == "" {
+		return f.Message
+	}
+	return fmt.Sprintf("%s:%d: %s", f.File, f.Line, f.Message)
+}
+
+type HTTPDConfigLinter struct {
+	logger scribe.Emitter
+}
+
+func NewHTTPDConfigLinter(logger scribe.Emitter) HTTPDConfigLinter {
+	return HTTPDConfigLinter{
+		logger: logger,
+	}
+}
+
+// Lint reports the container pitfalls in the config at configPath as
+// warnings. When strict is set, any finding fails the build.
+func (l HTTPDConfigLinter) Lint(serverRoot, appRoot, configPath string, strict bool) error {
+	l.logger.Process("Linting %s", filepath.Base(configPath))
+
+	directives, err := apacheconf.NewParser(map[string]string{
+		"SERVER_ROOT": serverRoot,
+		"APP_ROOT":    appRoot,
+	}).ParseFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", configPath, err)
+	}
+
+	findings := lintConfig(directives, serverRoot)
+	for _, finding := range findings {
+		l.logger.Subprocess("WARNING: %s", finding)
+	}
+
+	if len(findings) == 0 {
+		l.logger.Action("No issues found")
+	}
+	l.logger.Break()
+
+	if strict && len(findings) > 0 {
+		return fmt.Errorf("failed: %s has %d lint warning(s) and BP_HTTPD_LINT_STRICT is set", configPath, len(findings))
+	}
+
+	return nil
+}
+
+func lintConfig(directives []apacheconf.Directive, serverRoot string) []ConfigLintFinding {
+	var (
+		findings []ConfigLintFinding
+		pidFile  bool
+		errorLog bool
+		defined  = map[string]bool{}
+	)
+
+	add := func(directive apacheconf.Directive, format string, a ...interface{}) {
+		findings = append(findings, ConfigLintFinding{
+			File:    directive.File,
+			Line:    directive.Line,
+			Message: fmt.Sprintf(format, a...),
+		})
+	}
+
+	apacheconf.Walk(directives, func(directive apacheconf.Directive) {
+		if strings.EqualFold(directive.Name, "Define") && len(directive.Args) > 0 {
+			defined[directive.Args[0]] = true
+		}
+
+		for _, name := range directive.Variables() {
+			if !launchVariables[name] && !defined[name] {
+				add(directive, "%s references ${%s}, which is not set by the buildpack and must be set in the launch environment", directive.Name, name)
+			}
+		}
+
+		if len(directive.Args) == 0 {
+			return
+		}
+		arg := directive.Args[0]
+
+		switch strings.ToLower(directive.Name) {
+		case "listen":
+			if !strings.Contains(arg, "${PORT}") {
+				add(directive, "Listen %s is not bound to ${PORT}", arg)
+			}
+
+		case "errorlog":
+			errorLog = true
+			if !isStreamLog(arg) {
+				add(directive, "ErrorLog writes to %s instead of stderr", arg)
+			}
+
+		case "customlog", "transferlog":
+			if !isStreamLog(arg) {
+				add(directive, "%s writes to %s instead of stdout", directive.Name, arg)
+			}
+
+		case "loadmodule":
+			if len(directive.Args) < 2 {
+				return
+			}
+
+			path := strings.ReplaceAll(directive.Args[1], "${SERVER_ROOT}", serverRoot)
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(serverRoot, path)
+			}
+
+			if _, err := os.Stat(path); err != nil {
+				add(directive, "LoadModule %s refers to %s, which is not part of the installed httpd", arg, directive.Args[1])
+			}
+
+		case "pidfile":
+			pidFile = true
+			if !isWritable(arg) {
+				add(directive, "PidFile %s is not in a writable location such as /tmp", arg)
+			}
+		}
+	})
+
+	if !pidFile {
+		findings = append(findings, ConfigLintFinding{Message: "no PidFile is set and the default logs/httpd.pid under ServerRoot is not writable at launch"})
+	}
+
+	if !errorLog {
+		findings = append(findings, ConfigLintFinding{Message: "no ErrorLog is set and the default logs/error_log under ServerRoot is not writable at launch"})
+	}
+
+	return findings
+}
+
+func isStreamLog(target string) bool {
+	return strings.HasPrefix(target, "|") ||
+		strings.HasPrefix(target, "syslog") ||
+		strings.HasPrefix(target, "/proc/self/fd/") ||
+		target == "/dev/stdout" ||
+		target == "/dev/stderr"
+}
+
+func isWritable(path string) bool {
+	for _, prefix := range writablePrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package httpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/httpd"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testHTTPDConfigLinter(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		serverRoot string
+		workingDir string
+		configPath string
+		buffer     *bytes.Buffer
+
+		linter httpd.HTTPDConfigLinter
+	)
+
+	it.Before(func() {
+		var err error
+		serverRoot, err = os.MkdirTemp("", "server-root")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "working-dir")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(serverRoot, "modules"), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(serverRoot, "modules", "mod_mime.so"), nil, 0600)).To(Succeed())
+
+		configPath = filepath.Join(workingDir, "httpd.conf")
+
+		buffer = bytes.NewBuffer(nil)
+		linter = httpd.NewHTTPDConfigLinter(scribe.NewEmitter(buffer))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(serverRoot)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	context("Lint", func() {
+		context("when the config is container friendly", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(configPath, []byte(`ServerRoot "${SERVER_ROOT}"
+LoadModule mime_module modules/mod_mime.so
+PidFile /tmp/httpd.pid
+Listen "${PORT}"
+ErrorLog /proc/self/fd/2
+CustomLog "|/usr/bin/cat" common
+`), 0600)).To(Succeed())
+			})
+
+			it("reports no issues", func() {
+				err := linter.Lint(serverRoot, workingDir, configPath, true)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Linting httpd.conf"))
+				Expect(buffer.String()).To(ContainSubstring("No issues found"))
+				Expect(buffer.String()).NotTo(ContainSubstring("WARNING"))
+			})
+		})
+
+		context("when the config has container pitfalls", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(configPath, []byte(`ServerRoot "${SERVER_ROOT}"
+LoadModule mime_module modules/mod_mime.so
+LoadModule cgi_module "${SERVER_ROOT}/modules/mod_cgi.so"
+Listen 80
+<VirtualHost *:80>
+  ErrorLog logs/error_log
+  CustomLog /var/log/access_log common
+</VirtualHost>
+DocumentRoot "${DOCS}"
+`), 0600)).To(Succeed())
+			})
+
+			it("warns about each of them", func() {
+				err := linter.Lint(serverRoot, workingDir, configPath, false)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("WARNING: " + configPath + ":3: LoadModule cgi_module refers to ${SERVER_ROOT}/modules/mod_cgi.so, which is not part of the installed httpd"))
+				Expect(buffer.String()).To(ContainSubstring("WARNING: " + configPath + ":4: Listen 80 is not bound to ${PORT}"))
+				Expect(buffer.String()).To(ContainSubstring("WARNING: " + configPath + ":6: ErrorLog writes to logs/error_log instead of stderr"))
+				Expect(buffer.String()).To(ContainSubstring("WARNING: " + configPath + ":7: CustomLog writes to /var/log/access_log instead of stdout"))
+				Expect(buffer.String()).To(ContainSubstring("WARNING: " + configPath + ":9: DocumentRoot references ${DOCS}, which is not set by the buildpack and must be set in the launch environment"))
+				Expect(buffer.String()).To(ContainSubstring("WARNING: no PidFile is set and the default logs/httpd.pid under ServerRoot is not writable at launch"))
+				Expect(buffer.String()).NotTo(ContainSubstring("mime_module"))
+			})
+
+			context("when strict mode is enabled", func() {
+				it("returns an error", func() {
+					err := linter.Lint(serverRoot, workingDir, configPath, true)
+					Expect(err).To(MatchError("failed: " + configPath + " has 6 lint warning(s) and BP_HTTPD_LINT_STRICT is set"))
+				})
+			})
+		})
+
+		context("when the PidFile is on a read-only path", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(configPath, []byte("PidFile logs/httpd.pid\nErrorLog /dev/stderr\nListen ${PORT}\n"), 0600)).To(Succeed())
+			})
+
+			it("warns about it", func() {
+				err := linter.Lint(serverRoot, workingDir, configPath, false)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("WARNING: " + configPath + ":1: PidFile logs/httpd.pid is not in a writable location such as /tmp"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the config cannot be parsed", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(configPath, []byte("<Directory />\n"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					err := linter.Lint(serverRoot, workingDir, configPath, false)
+					Expect(err).To(MatchError(ContainSubstring("failed to parse " + configPath)))
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,48 @@
+package httpd
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// validateConfigValue rejects a value that is interpolated into a quoted
+// httpd.conf argument if it could end the argument, start a new directive or
+// be expanded as a variable when httpd starts.
+func validateConfigValue(name, value string) error {
+	if strings.ContainsAny(value, "\"\\") || strings.Contains(value, "${") || strings.IndexFunc(value, unicode.IsControl) >= 0 {
+		return fmt.Errorf("failed: %s %q must not contain quotes, backslashes, control characters or '${'", name, value)
+	}
+
+	return nil
+}
+
+// validateBuildEnvironment checks the user supplied values that are written
+// into httpd.conf before any of them are used.
+func validateBuildEnvironment(buildEnvironment BuildEnvironment) error {
+	values := []struct {
+		name  string
+		value string
+	}{
+		{"BP_WEB_SERVER_ROOT", buildEnvironment.WebServerRoot},
+		{"BP_WEB_SERVER_PROXY_UPSTREAM", buildEnvironment.WebServerProxyUpstream},
+		{"BP_WEB_SERVER_FASTCGI_ADDRESS", buildEnvironment.WebServerFastCGIAddress},
+		{"BP_WEB_SERVER_COMPRESSION_TYPES", buildEnvironment.WebServerCompressionTypes},
+		{"BP_WEB_SERVER_TLS_PORT", buildEnvironment.WebServerTLSPort},
+		{"BP_WEB_SERVER_PUSH_STATE_FALLBACK", buildEnvironment.WebServerPushStateFallback},
+		{"BP_WEB_SERVER_PUSH_STATE_EXCLUDE", buildEnvironment.WebServerPushStateExclude},
+	}
+
+	for _, v := range values {
+		err := validateConfigValue(v.name, v.value)
+		if err != nil {
+			return err
+		}
+	}
+
+	if strings.IndexFunc(buildEnvironment.WebServerProxyUpstream, unicode.IsSpace) >= 0 {
+		return fmt.Errorf("failed: BP_WEB_SERVER_PROXY_UPSTREAM %q must not contain whitespace", buildEnvironment.WebServerProxyUpstream)
+	}
+
+	return nil
+}
@@ -11,13 +11,50 @@ LoadModule mime_module modules/mod_mime.so
 LoadModule dir_module modules/mod_dir.so
 LoadModule authz_core_module modules/mod_authz_core.so
 LoadModule unixd_module modules/mod_unixd.so
-{{if or .WebServerPushStateEnabled .WebServerForceHTTPS -}}
+LoadModule headers_module modules/mod_headers.so
+LoadModule unique_id_module modules/mod_unique_id.so
+{{if or .WebServerPushStateEnabled .WebServerForceHTTPS .WebServerProxyUpstream .WebServerPrecompressEnabled .Redirects .WebServerStatusPath (eq .WebServerAccessLogFormat "json") -}}
 LoadModule rewrite_module modules/mod_rewrite.so
 {{end}}
+{{- if eq .WebServerAccessLogFormat "json" -}}
+LoadModule setenvif_module modules/mod_setenvif.so
+{{end}}
+{{- if or .WebServerProxyUpstream .WebServerFastCGIAddress .RedirectsProxy -}}
+LoadModule proxy_module modules/mod_proxy.so
+{{end}}
+{{- if or .WebServerProxyUpstream .RedirectsProxy -}}
+LoadModule proxy_http_module modules/mod_proxy_http.so
+{{end}}
+{{- if .WebServerFastCGIAddress -}}
+LoadModule proxy_fcgi_module modules/mod_proxy_fcgi.so
+{{end}}
+{{- if .CachePolicies -}}
+LoadModule expires_module modules/mod_expires.so
+{{end}}
+{{- if .WebServerCompressionEnabled -}}
+LoadModule filter_module modules/mod_filter.so
+LoadModule deflate_module modules/mod_deflate.so
+<IfFile modules/mod_brotli.so>
+  LoadModule brotli_module modules/mod_brotli.so
+</IfFile>
+{{end}}
 {{- if .WebServerPushStateEnabled -}}
 LoadModule autoindex_module modules/mod_autoindex.so
 {{end}}
-{{- if .BasicAuthFile -}}
+{{- if .WebServerHealthCheckPath -}}
+LoadModule alias_module modules/mod_alias.so
+{{end}}
+{{- if .WebServerStatusPath -}}
+LoadModule status_module modules/mod_status.so
+{{end}}
+{{- if and .WebServerStatusPath (not (or .BasicAuthFile .BasicAuthLocations)) -}}
+LoadModule authz_host_module modules/mod_authz_host.so
+{{end}}
+{{- if .TLSCertificateFile -}}
+LoadModule socache_shmcb_module modules/mod_socache_shmcb.so
+LoadModule ssl_module modules/mod_ssl.so
+{{end}}
+{{- if or .BasicAuthFile .BasicAuthLocations -}}
 LoadModule authn_core_module modules/mod_authn_core.so
 LoadModule authn_file_module modules/mod_authn_file.so
 LoadModule authz_host_module modules/mod_authz_host.so
@@ -31,16 +68,78 @@ PidFile /tmp/httpd.pid
 
 User nobody
 
+ServerLimit ${HTTPD_SERVER_LIMIT}
+ThreadsPerChild ${HTTPD_THREADS_PER_CHILD}
+MaxRequestWorkers ${HTTPD_MAX_REQUEST_WORKERS}
+MaxConnectionsPerChild ${HTTPD_MAX_CONNECTIONS_PER_CHILD}
+
 Listen "${PORT}"
+{{- if .TLSCertificateFile}}
+Listen {{.WebServerTLSPort}} https
+{{- end}}
 
 DocumentRoot "{{.WebServerRoot}}"
 
-DirectoryIndex index.html
+DirectoryIndex {{if .WebServerFastCGIAddress}}index.php {{end}}index.html
 
 ErrorLog /proc/self/fd/2
+ErrorLogFormat "[%{u}t] [%-m:%l] [pid %P:tid %T] [request_id\ %{X-Request-ID}i] [unique_id\ %{UNIQUE_ID}e] %7F: %E: [client\ %a] %M% ,\ referer\ %{Referer}i"
+{{- if ne .WebServerAccessLogFormat "off"}}
+
+LogFormat "{{.AccessLogFormat}}" {{.WebServerAccessLogFormat}}
+CustomLog /proc/self/fd/1 {{.WebServerAccessLogFormat}}
+{{- end}}
+{{- if eq .WebServerAccessLogFormat "json"}}
+
+RewriteEngine On
+RewriteOptions InheritDown
+RewriteCond %{THE_REQUEST} "^\S+ ([\x21-\x3e\x40-\x7e]+)[? ]"
+RewriteRule ^ - [E=ACCESS_LOG_PATH:%1]
+SetEnvIf User-Agent "^([\x20-\x7e]+)$" ACCESS_LOG_USER_AGENT=$1
+SetEnvIf X-Forwarded-For "^([\x20-\x7e]+)$" ACCESS_LOG_FORWARDED_FOR=$1
+{{- end}}
+
+RequestHeader setifempty X-Request-ID "%{UNIQUE_ID}e"
+Header always set X-Request-ID "expr=%{req:X-Request-ID}"
+Header always set X-Request-ID "%{UNIQUE_ID}e" "expr=-z %{req:X-Request-ID}"
+{{- if .WebServerProxyUpstream}}
 
-LogFormat "%h %l %u %t \"%r\" %>s %b" common
-CustomLog /proc/self/fd/1 common
+ProxyPreserveHost On
+ProxyPassReverse "/" "{{.WebServerProxyUpstream}}/"
+{{- end}}
+{{- if .WebServerCompressionEnabled}}
+
+<IfModule brotli_module>
+  AddOutputFilterByType BROTLI_COMPRESS;DEFLATE {{.WebServerCompressionTypes}}
+</IfModule>
+<IfModule !brotli_module>
+  AddOutputFilterByType DEFLATE {{.WebServerCompressionTypes}}
+</IfModule>
+{{- end}}
+{{- if .SecurityHeaders}}
+{{range .SecurityHeaders}}
+Header always set {{.Name}} "{{.Value}}"
+{{- end}}
+{{- end}}
+{{- if .ErrorDocuments}}
+{{range .ErrorDocuments}}
+ErrorDocument {{.Code}} {{.Path}}
+{{- end}}
+{{- end}}
+{{- if .TLSCertificateFile}}
+
+SSLSessionCache "shmcb:/tmp/ssl_scache(512000)"
+SSLProtocol all -SSLv3 -TLSv1 -TLSv1.1
+
+<VirtualHost *:{{.WebServerTLSPort}}>
+  SSLEngine on
+  SSLCertificateFile "{{.TLSCertificateFile}}"
+  SSLCertificateKeyFile "{{.TLSKeyFile}}"
+{{- if .TLSCAFile}}
+  SSLCertificateChainFile "{{.TLSCAFile}}"
+{{- end}}
+</VirtualHost>
+{{- end}}
 
 <Directory />
   AllowOverride None
@@ -53,6 +152,29 @@ CustomLog /proc/self/fd/1 common
 {{- else}}
   Require all granted
 {{- end}}
+{{- if .WebServerStatusPath}}
+
+  RewriteEngine On
+  RewriteRule "{{.StatusPathPattern}}" "-" [END]
+{{- end}}
+{{- if .WebServerForceHTTPS}}
+
+  RewriteEngine On
+  RewriteCond %{HTTPS} !=on
+  RewriteCond %{HTTP:X-Forwarded-Proto} !https [NC]
+  RewriteRule ^ https://%{HTTP_HOST}%{REQUEST_URI} [L,R=301]
+{{- end}}
+{{- if .Redirects}}
+
+  RewriteEngine On
+{{- range .Redirects}}
+{{- if not .Forced}}
+  RewriteCond %{REQUEST_FILENAME} !-f
+  RewriteCond %{REQUEST_FILENAME} !-d
+{{- end}}
+  RewriteRule "{{.Pattern}}" "{{.Substitution}}" [{{.Flags}}]
+{{- end}}
+{{- end}}
 {{- if .WebServerPushStateEnabled}}
 
   Options +FollowSymLinks
@@ -60,27 +182,159 @@ CustomLog /proc/self/fd/1 common
   RewriteEngine On
   RewriteCond %{REQUEST_FILENAME} !-f
   RewriteCond %{REQUEST_FILENAME} !-d
-  RewriteRule (.*) index.html
+{{- range .PushStateExcludePatterns}}
+  RewriteCond %{REQUEST_URI} !{{.}}
 {{- end}}
-{{- if .WebServerForceHTTPS}}
+  RewriteRule (.*) {{.WebServerPushStateFallback}}
+{{- end}}
+{{- if .WebServerPrecompressEnabled}}
 
   RewriteEngine On
-  RewriteCond %{HTTPS} !=on
-  RewriteCond %{HTTP:X-Forwarded-Proto} !https [NC]
-  RewriteRule ^ https://%{HTTP_HOST}%{REQUEST_URI} [L,R=301]
+  RewriteCond "%{HTTP:Accept-Encoding}" "br"
+  RewriteCond "%{REQUEST_FILENAME}.br" -s
+  RewriteRule "^(.*)\.(css|html|js|json|map|mjs|svg|txt|wasm|xml)$" "$1.$2.br" [QSA]
+  RewriteCond "%{HTTP:Accept-Encoding}" "gzip"
+  RewriteCond "%{REQUEST_FILENAME}.gz" -s
+  RewriteRule "^(.*)\.(css|html|js|json|map|mjs|svg|txt|wasm|xml)$" "$1.$2.gz" [QSA]
+  RewriteRule "\.css\.(br|gz)$" "-" [T=text/css,E=no-brotli:1,E=no-gzip:1]
+  RewriteRule "\.html\.(br|gz)$" "-" [T=text/html,E=no-brotli:1,E=no-gzip:1]
+  RewriteRule "\.m?js\.(br|gz)$" "-" [T=text/javascript,E=no-brotli:1,E=no-gzip:1]
+  RewriteRule "\.(json|map)\.(br|gz)$" "-" [T=application/json,E=no-brotli:1,E=no-gzip:1]
+  RewriteRule "\.svg\.(br|gz)$" "-" [T=image/svg+xml,E=no-brotli:1,E=no-gzip:1]
+  RewriteRule "\.txt\.(br|gz)$" "-" [T=text/plain,E=no-brotli:1,E=no-gzip:1]
+  RewriteRule "\.wasm\.(br|gz)$" "-" [T=application/wasm,E=no-brotli:1,E=no-gzip:1]
+  RewriteRule "\.xml\.(br|gz)$" "-" [T=application/xml,E=no-brotli:1,E=no-gzip:1]
+
+  <FilesMatch "\.(css|html|js|json|map|mjs|svg|txt|wasm|xml)(\.br|\.gz)?$">
+    Header merge Vary Accept-Encoding
+  </FilesMatch>
+
+  <FilesMatch "\.(css|html|js|json|map|mjs|svg|txt|wasm|xml)\.br$">
+    Header set Content-Encoding br
+  </FilesMatch>
+
+  <FilesMatch "\.(css|html|js|json|map|mjs|svg|txt|wasm|xml)\.gz$">
+    Header set Content-Encoding gzip
+  </FilesMatch>
+{{- end}}
+{{- if .WebServerProxyUpstream}}
+
+  RewriteEngine On
+  RewriteCond %{REQUEST_FILENAME} !-f
+  RewriteRule ^ {{.WebServerProxyUpstream}}%{REQUEST_URI} [P,L]
+{{- end}}
+{{- if .WebServerFastCGIAddress}}
+
+  FallbackResource /index.php
+
+  <FilesMatch "\.php$">
+    SetHandler "proxy:{{.WebServerFastCGIAddress}}"
+  </FilesMatch>
 {{- end}}
 {{- if .BasicAuthFile}}
 
   AuthType Basic
-  AuthName "Authentication Required"
+  AuthName "{{.BasicAuthRealm}}"
   AuthUserFile "{{.BasicAuthFile}}"
 
   Order allow,deny
   Allow from all
 {{- end}}
 </Directory>
+{{- range .BasicAuthLocations}}
+
+<Location "{{.Path}}">
+  AuthType Basic
+  AuthName "{{.Realm}}"
+  AuthUserFile "{{.File}}"
+  Require valid-user
+</Location>
+{{- end}}
+{{- if .WebServerHealthCheckPath}}
+
+Alias "{{.WebServerHealthCheckPath}}" "{{.HealthCheckFilePath}}"
+
+<Location "{{.WebServerHealthCheckPath}}">
+  Require all granted
+</Location>
+{{- end}}
+{{- if .WebServerStatusPath}}
+
+<Location "{{.WebServerStatusPath}}">
+  SetHandler server-status
+  Require ip {{.WebServerStatusAllow}}
+{{- if .WebServerFastCGIAddress}}
+  FallbackResource disabled
+{{- end}}
+</Location>
+{{- end}}
+{{- range .CachePolicies}}
+
+<{{.Section}} "{{.Pattern}}">
+{{- if .MaxAge}}
+  ExpiresActive On
+  ExpiresDefault "access plus {{.MaxAge}} seconds"
+{{- end}}
+  Header set Cache-Control "{{.CacheControl}}"
+</{{.Section}}>
+{{- end}}
+{{- range .RedirectErrorDocuments}}
+
+<LocationMatch "{{.Pattern}}">
+  ErrorDocument {{.Code}} {{.Path}}
+</LocationMatch>
+{{- end}}
+{{- range .HeaderRules}}
+
+<LocationMatch "{{.Pattern}}">
+{{- range .Headers}}
+  Header set {{.Name}} "{{.Value}}"
+{{- end}}
+</LocationMatch>
+{{- end}}
 
 <Files ".ht*">
   Require all denied
-</Files>`
+</Files>
+{{- if .RuleFilesPresent}}
+
+<FilesMatch "^_(redirects|headers)$">
+  Require all denied
+</FilesMatch>
+{{- end}}
+{{- if .ConfigIncludes}}
+{{range .ConfigIncludes}}
+Include "{{.}}"
+{{- end}}
+{{- end}}`
+)
+
+const (
+	roadRunnerConf = `version: "3"
+
+server:
+  command: {{quote .RoadRunnerWorkerCommand}}
+
+http:
+  address: {{quote .RoadRunnerHTTPAddress}}
+{{- if .RoadRunnerStaticDir}}
+  middleware: ["static"]
+  static:
+    dir: {{quote .RoadRunnerStaticDir}}
+    forbid: [".php", ".htaccess"]
+{{- end}}
+{{- if or .RoadRunnerNumWorkers .RoadRunnerMaxJobs}}
+  pool:
+{{- if .RoadRunnerNumWorkers}}
+    num_workers: {{.RoadRunnerNumWorkers}}
+{{- end}}
+{{- if .RoadRunnerMaxJobs}}
+    max_jobs: {{.RoadRunnerMaxJobs}}
+{{- end}}
+{{- end}}
+
+logs:
+  mode: production
+  output: stderr
+`
 )
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/joshuatcasey/libdependency/retrieve"
+	"github.com/joshuatcasey/libdependency/versionology"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/paketo-buildpacks/packit/v2/vacation"
+)
+
+type RoadRunnerMetadata struct {
+	SemverVersion *semver.Version
+}
+
+type RoadRunnerRelease struct {
+	TagName    string `json:"tag_name"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+func (roadRunnerMetadata RoadRunnerMetadata) Version() *semver.Version {
+	return roadRunnerMetadata.SemverVersion
+}
+
+// RoadRunner is released as a static binary, so the upstream linux-amd64
+// archive is used as is on every stack and nothing is compiled.
+func main() {
+	retrieve.NewMetadata("roadrunner", getRoadRunnerVersions, generateMetadata)
+}
+
+func getReleases() ([]RoadRunnerRelease, error) {
+	resp, err := http.Get("https://api.github.com/repos/roadrunner-server/roadrunner/releases?per_page=100")
+	if err != nil {
+		return nil, fmt.Errorf("could not get releases from api.github.com: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not get releases from api.github.com: unexpected status %s", resp.Status)
+	}
+
+	var releases []RoadRunnerRelease
+	err = json.NewDecoder(resp.Body).Decode(&releases)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse releases: %w", err)
+	}
+
+	return releases, nil
+}
+
+func getRoadRunnerVersions() (versionology.VersionFetcherArray, error) {
+	releases, err := getReleases()
+	if err != nil {
+		return nil, fmt.Errorf("could not get releases: %w", err)
+	}
+
+	var versions []versionology.VersionFetcher
+	for _, release := range releases {
+		if release.Draft || release.Prerelease {
+			continue
+		}
+
+		version, err := semver.NewVersion(strings.TrimPrefix(release.TagName, "v"))
+		if err != nil || version.Prerelease() != "" {
+			continue
+		}
+
+		versions = append(versions, RoadRunnerMetadata{version})
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version().GreaterThan(versions[j].Version())
+	})
+
+	return versions, nil
+}
+
+func generateMetadata(hasVersion versionology.VersionFetcher) ([]versionology.Dependency, error) {
+	roadRunnerVersion := hasVersion.Version().String()
+
+	uri := fmt.Sprintf("https://github.com/roadrunner-server/roadrunner/releases/download/v%[1]s/roadrunner-%[1]s-linux-amd64.tar.gz", roadRunnerVersion)
+	source := fmt.Sprintf("https://github.com/roadrunner-server/roadrunner/archive/refs/tags/v%s.tar.gz", roadRunnerVersion)
+
+	sha, err := getSHA256(uri)
+	if err != nil {
+		return nil, fmt.Errorf("could not get sha: %w", err)
+	}
+
+	sourceSHA, err := getSHA256(source)
+	if err != nil {
+		return nil, fmt.Errorf("could not get source sha: %w", err)
+	}
+
+	dep := cargo.ConfigMetadataDependency{
+		Version:         roadRunnerVersion,
+		ID:              "roadrunner",
+		Name:            "RoadRunner",
+		Checksum:        fmt.Sprintf("sha256:%s", sha),
+		URI:             uri,
+		Source:          source,
+		SourceChecksum:  fmt.Sprintf("sha256:%s", sourceSHA),
+		StripComponents: 1,
+		DeprecationDate: nil,
+		Licenses:        retrieve.LookupLicenses(source, decompress),
+		PURL:            retrieve.GeneratePURL("roadrunner", roadRunnerVersion, sourceSHA, source),
+		CPE:             fmt.Sprintf("cpe:2.3:a:spiralscout:roadrunner:%s:*:*:*:*:*:*:*", roadRunnerVersion),
+		Stacks:          []string{"io.buildpacks.stacks.bionic"},
+	}
+
+	bionicDependency, err := versionology.NewDependency(dep, "bionic")
+	if err != nil {
+		return nil, fmt.Errorf("could not create bionic dependency: %w", err)
+	}
+
+	dep.Stacks = []string{"io.buildpacks.stacks.jammy"}
+
+	jammyDependency, err := versionology.NewDependency(dep, "jammy")
+	if err != nil {
+		return nil, fmt.Errorf("could not create jammy dependency: %w", err)
+	}
+
+	return []versionology.Dependency{bionicDependency, jammyDependency}, nil
+}
+
+func getSHA256(url string) (string, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: unexpected status %s", url, resp.Status)
+	}
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to calculate SHA256: %w", err)
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+func decompress(artifact io.Reader, destination string) error {
+	archive := vacation.NewArchive(artifact)
+
+	err := archive.StripComponents(1).Decompress(destination)
+	if err != nil {
+		return fmt.Errorf("failed to decompress source file: %w", err)
+	}
+
+	return nil
+}
@@ -5,23 +5,61 @@ import (
 
 	"github.com/paketo-buildpacks/packit/v2"
 	"github.com/paketo-buildpacks/packit/v2/fs"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
 )
 
-const PlanDependencyHTTPD = "httpd"
+const (
+	PlanDependencyHTTPD      = "httpd"
+	PlanDependencyRoadRunner = "roadrunner"
+)
 
 //go:generate faux --interface Parser --output fakes/parser.go
 type Parser interface {
 	ParseVersion(path string) (version, versionSource string, err error)
 }
 
+//go:generate faux --interface ComposerParser --output fakes/composer_parser.go
+type ComposerParser interface {
+	ParseRoadRunner(workingDir string) (required bool, version, versionSource string, err error)
+}
+
 type BuildPlanMetadata struct {
 	Version       string `toml:"version,omitempty"`
 	VersionSource string `toml:"version-source,omitempty"`
 	Launch        bool   `toml:"launch"`
 }
 
-func Detect(buildEnvironment BuildEnvironment, parser Parser) packit.DetectFunc {
+func Detect(buildEnvironment BuildEnvironment, parser Parser, composerParser ComposerParser, logger scribe.Emitter) packit.DetectFunc {
 	return func(context packit.DetectContext) (packit.DetectResult, error) {
+		exists, err := fs.Exists(filepath.Join(context.WorkingDir, "httpd.conf"))
+		if err != nil {
+			return packit.DetectResult{}, err
+		}
+
+		// An app with its own httpd.conf keeps being served by httpd, whatever
+		// its composer files require.
+		if buildEnvironment.WebServer == "roadrunner" || (buildEnvironment.WebServer == "" && !exists) {
+			// Composer files that cannot be parsed do not fail detection: the app
+			// may still be served by httpd, and composer reports them at install.
+			required, version, versionSource, err := composerParser.ParseRoadRunner(context.WorkingDir)
+			if err != nil {
+				logger.Process("WARNING: RoadRunner is not detected from the composer files: %s", err)
+				required, version, versionSource = false, "", ""
+			}
+
+			if buildEnvironment.WebServer == "roadrunner" {
+				return detectRoadRunner(buildEnvironment, version, versionSource), nil
+			}
+
+			// A roadrunner detected from composer files still provides httpd, so
+			// that buildpacks later in the group that require httpd pass.
+			if required {
+				result := detectRoadRunner(buildEnvironment, version, versionSource)
+				result.Plan.Provides = append(result.Plan.Provides, packit.BuildPlanProvision{Name: PlanDependencyHTTPD})
+				return result, nil
+			}
+		}
+
 		plan := packit.DetectResult{
 			Plan: packit.BuildPlan{
 				Provides: []packit.BuildPlanProvision{
@@ -39,12 +77,16 @@ func Detect(buildEnvironment BuildEnvironment, parser Parser) packit.DetectFunc
 					Launch: true,
 				},
 			})
-			plan.Plan.Requires = requirements
-		}
 
-		exists, err := fs.Exists(filepath.Join(context.WorkingDir, "httpd.conf"))
-		if err != nil {
-			return packit.DetectResult{}, err
+			if buildEnvironment.WebServerFastCGIAddress != "" {
+				requirements = append(requirements, packit.BuildPlanRequirement{
+					Name: "php-fpm",
+					Metadata: map[string]interface{}{
+						"launch": true,
+					},
+				})
+			}
+			plan.Plan.Requires = requirements
 		}
 
 		if !exists {
@@ -92,3 +134,54 @@ func Detect(buildEnvironment BuildEnvironment, parser Parser) packit.DetectFunc
 		return plan, nil
 	}
 }
+
+func detectRoadRunner(buildEnvironment BuildEnvironment, version, versionSource string) packit.DetectResult {
+	requirements := []packit.BuildPlanRequirement{
+		{
+			Name: PlanDependencyRoadRunner,
+			Metadata: BuildPlanMetadata{
+				Launch: true,
+			},
+		},
+	}
+
+	if buildEnvironment.RoadRunnerVersion != "" {
+		requirements = append(requirements, packit.BuildPlanRequirement{
+			Name: PlanDependencyRoadRunner,
+			Metadata: BuildPlanMetadata{
+				Version:       buildEnvironment.RoadRunnerVersion,
+				VersionSource: "BP_ROADRUNNER_VERSION",
+				Launch:        true,
+			},
+		})
+	}
+
+	if version != "" {
+		requirements = append(requirements, packit.BuildPlanRequirement{
+			Name: PlanDependencyRoadRunner,
+			Metadata: BuildPlanMetadata{
+				Version:       version,
+				VersionSource: versionSource,
+				Launch:        true,
+			},
+		})
+	}
+
+	if buildEnvironment.Reload {
+		requirements = append(requirements, packit.BuildPlanRequirement{
+			Name: "watchexec",
+			Metadata: map[string]interface{}{
+				"launch": true,
+			},
+		})
+	}
+
+	return packit.DetectResult{
+		Plan: packit.BuildPlan{
+			Provides: []packit.BuildPlanProvision{
+				{Name: PlanDependencyRoadRunner},
+			},
+			Requires: requirements,
+		},
+	}
+}
@@ -1,6 +1,7 @@
 package httpd_test
 
 import (
+	"bytes"
 	"errors"
 	"os"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 	"github.com/paketo-buildpacks/httpd"
 	"github.com/paketo-buildpacks/httpd/fakes"
 	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
 	"github.com/sclevine/spec"
 
 	. "github.com/onsi/gomega"
@@ -18,7 +20,9 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 	var (
 		Expect = NewWithT(t).Expect
 
-		parser *fakes.Parser
+		parser         *fakes.Parser
+		composerParser *fakes.ComposerParser
+		buffer         *bytes.Buffer
 
 		workingDir string
 		detect     packit.DetectFunc
@@ -36,7 +40,10 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 		parser.ParseVersionCall.Returns.Version = "some-version"
 		parser.ParseVersionCall.Returns.VersionSource = "some-version-source"
 
-		detect = httpd.Detect(httpd.BuildEnvironment{}, parser)
+		composerParser = &fakes.ComposerParser{}
+		buffer = bytes.NewBuffer(nil)
+
+		detect = httpd.Detect(httpd.BuildEnvironment{}, parser, composerParser, scribe.NewEmitter(buffer))
 	})
 
 	it.After(func() {
@@ -73,6 +80,8 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 						WebServer: "httpd",
 					},
 					parser,
+					composerParser,
+					scribe.NewEmitter(buffer),
 				)
 			})
 
@@ -100,6 +109,43 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("when BP_WEB_SERVER=httpd and BP_WEB_SERVER_FASTCGI_ADDRESS is set", func() {
+		it.Before(func() {
+			Expect(os.Remove(filepath.Join(workingDir, "httpd.conf"))).To(Succeed())
+
+			detect = httpd.Detect(
+				httpd.BuildEnvironment{
+					WebServer:               "httpd",
+					WebServerFastCGIAddress: "9000",
+				},
+				parser,
+				composerParser,
+				scribe.NewEmitter(buffer),
+			)
+		})
+
+		it("requires php-fpm at launch time", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Requires).To(Equal([]packit.BuildPlanRequirement{
+				{
+					Name: httpd.PlanDependencyHTTPD,
+					Metadata: httpd.BuildPlanMetadata{
+						Launch: true,
+					},
+				},
+				{
+					Name: "php-fpm",
+					Metadata: map[string]interface{}{
+						"launch": true,
+					},
+				},
+			}))
+		})
+	})
+
 	context("when there is an httpd.conf file in the workspace", func() {
 		it("returns a DetectResult that provides and required httpd", func() {
 			result, err := detect(packit.DetectContext{
@@ -134,6 +180,8 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 						Reload: true,
 					},
 					parser,
+					composerParser,
+					scribe.NewEmitter(buffer),
 				)
 			})
 
@@ -163,6 +211,222 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("when BP_WEB_SERVER=roadrunner", func() {
+		it.Before(func() {
+			detect = httpd.Detect(
+				httpd.BuildEnvironment{
+					WebServer: "roadrunner",
+				},
+				parser,
+				composerParser,
+				scribe.NewEmitter(buffer),
+			)
+		})
+
+		it("provides and requires roadrunner", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(packit.DetectResult{
+				Plan: packit.BuildPlan{
+					Provides: []packit.BuildPlanProvision{
+						{Name: httpd.PlanDependencyRoadRunner},
+					},
+					Requires: []packit.BuildPlanRequirement{
+						{
+							Name: httpd.PlanDependencyRoadRunner,
+							Metadata: httpd.BuildPlanMetadata{
+								Launch: true,
+							},
+						},
+					},
+				},
+			}))
+
+			Expect(parser.ParseVersionCall.CallCount).To(Equal(0))
+		})
+
+		context("and BP_ROADRUNNER_VERSION is set", func() {
+			it.Before(func() {
+				detect = httpd.Detect(
+					httpd.BuildEnvironment{
+						WebServer:         "roadrunner",
+						RoadRunnerVersion: "env-var-version",
+						Reload:            true,
+					},
+					parser,
+					composerParser,
+					scribe.NewEmitter(buffer),
+				)
+			})
+
+			it("requires the specified version of roadrunner", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan.Requires).To(Equal([]packit.BuildPlanRequirement{
+					{
+						Name: httpd.PlanDependencyRoadRunner,
+						Metadata: httpd.BuildPlanMetadata{
+							Launch: true,
+						},
+					},
+					{
+						Name: httpd.PlanDependencyRoadRunner,
+						Metadata: httpd.BuildPlanMetadata{
+							Version:       "env-var-version",
+							VersionSource: "BP_ROADRUNNER_VERSION",
+							Launch:        true,
+						},
+					},
+					{
+						Name: "watchexec",
+						Metadata: map[string]interface{}{
+							"launch": true,
+						},
+					},
+				}))
+			})
+		})
+	})
+
+	context("when the composer files cannot be parsed", func() {
+		it.Before(func() {
+			Expect(os.Remove(filepath.Join(workingDir, "httpd.conf"))).To(Succeed())
+			composerParser.ParseRoadRunnerCall.Returns.Err = errors.New("failed to parse composer.json")
+		})
+
+		it("does not detect roadrunner and logs why", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Provides).To(Equal([]packit.BuildPlanProvision{
+				{Name: httpd.PlanDependencyHTTPD},
+			}))
+
+			Expect(buffer.String()).To(ContainSubstring("WARNING: RoadRunner is not detected from the composer files: failed to parse composer.json"))
+		})
+	})
+
+	context("when the composer files require roadrunner", func() {
+		it.Before(func() {
+			Expect(os.Remove(filepath.Join(workingDir, "httpd.conf"))).To(Succeed())
+			composerParser.ParseRoadRunnerCall.Returns.Required = true
+			composerParser.ParseRoadRunnerCall.Returns.Version = "2023.3.7"
+			composerParser.ParseRoadRunnerCall.Returns.VersionSource = "composer.lock"
+		})
+
+		it("provides roadrunner and httpd and requires roadrunner with the locked version", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(packit.DetectResult{
+				Plan: packit.BuildPlan{
+					Provides: []packit.BuildPlanProvision{
+						{Name: httpd.PlanDependencyRoadRunner},
+						{Name: httpd.PlanDependencyHTTPD},
+					},
+					Requires: []packit.BuildPlanRequirement{
+						{
+							Name: httpd.PlanDependencyRoadRunner,
+							Metadata: httpd.BuildPlanMetadata{
+								Launch: true,
+							},
+						},
+						{
+							Name: httpd.PlanDependencyRoadRunner,
+							Metadata: httpd.BuildPlanMetadata{
+								Version:       "2023.3.7",
+								VersionSource: "composer.lock",
+								Launch:        true,
+							},
+						},
+					},
+				},
+			}))
+
+			Expect(composerParser.ParseRoadRunnerCall.Receives.WorkingDir).To(Equal(workingDir))
+		})
+
+		context("when BP_WEB_SERVER=httpd", func() {
+			it.Before(func() {
+				detect = httpd.Detect(
+					httpd.BuildEnvironment{
+						WebServer: "httpd",
+					},
+					parser,
+					composerParser,
+					scribe.NewEmitter(buffer),
+				)
+			})
+
+			it("does not read the composer files", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan.Provides).To(Equal([]packit.BuildPlanProvision{
+					{Name: httpd.PlanDependencyHTTPD},
+				}))
+
+				Expect(composerParser.ParseRoadRunnerCall.CallCount).To(Equal(0))
+			})
+		})
+
+		context("when the app contains an httpd.conf", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "httpd.conf"), nil, 0600)).To(Succeed())
+
+				detect = httpd.Detect(
+					httpd.BuildEnvironment{
+						HTTPDVersion: "env-var-version",
+					},
+					parser,
+					composerParser,
+					scribe.NewEmitter(buffer),
+				)
+			})
+
+			it("keeps requiring httpd and does not read the composer files", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(Equal(packit.DetectResult{
+					Plan: packit.BuildPlan{
+						Provides: []packit.BuildPlanProvision{
+							{Name: httpd.PlanDependencyHTTPD},
+						},
+						Requires: []packit.BuildPlanRequirement{
+							{
+								Name: httpd.PlanDependencyHTTPD,
+								Metadata: httpd.BuildPlanMetadata{
+									Version:       "env-var-version",
+									VersionSource: "BP_HTTPD_VERSION",
+									Launch:        true,
+								},
+							},
+							{
+								Name: httpd.PlanDependencyHTTPD,
+								Metadata: httpd.BuildPlanMetadata{
+									Version:       "some-version",
+									VersionSource: "some-version-source",
+									Launch:        true,
+								},
+							},
+						},
+					},
+				}))
+
+				Expect(composerParser.ParseRoadRunnerCall.CallCount).To(Equal(0))
+			})
+		})
+	})
+
 	context("BP_HTTPD_VERSION is set", func() {
 		it.Before(func() {
 			detect = httpd.Detect(
@@ -170,6 +434,8 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 					HTTPDVersion: "env-var-version",
 				},
 				parser,
+				composerParser,
+				scribe.NewEmitter(buffer),
 			)
 		})
 
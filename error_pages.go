@@ -0,0 +1,84 @@
+package httpd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var errorPageRegexp = regexp.MustCompile(`^([45]\d\d)\.html$`)
+
+// serverErrorCodes are the statuses served by a 50x.html page.
+var serverErrorCodes = []int{500, 502, 503, 504}
+
+// errorDocuments merges the error pages set in spec with the conventionally
+// named pages in siteRoot, which they take precedence over. Rules in spec take
+// the form "<code>=<path>" and are separated by commas. When push state is enabled, a 404.html page in siteRoot
+// is ignored so that the push state fallback handles unknown paths.
+func errorDocuments(siteRoot, spec string, pushState bool) ([]ErrorDocument, error) {
+	documents := map[int]string{}
+
+	entries, err := os.ReadDir(siteRoot)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		if entry.Name() == "50x.html" {
+			for _, code := range serverErrorCodes {
+				if _, ok := documents[code]; !ok {
+					documents[code] = "/50x.html"
+				}
+			}
+			continue
+		}
+
+		matches := errorPageRegexp.FindStringSubmatch(entry.Name())
+		if matches == nil || (pushState && matches[1] == "404") {
+			continue
+		}
+
+		code, _ := strconv.Atoi(matches[1])
+		documents[code] = "/" + entry.Name()
+	}
+
+	for _, rule := range strings.Split(spec, ",") {
+		if strings.TrimSpace(rule) == "" {
+			continue
+		}
+
+		value, path, ok := strings.Cut(rule, "=")
+		value, path = strings.TrimSpace(value), strings.TrimSpace(path)
+
+		code, err := strconv.Atoi(value)
+		if !ok || err != nil || code < 400 || code > 599 {
+			return nil, fmt.Errorf("failed to parse error page %q: expected <status>=<path> with a 4xx or 5xx status", rule)
+		}
+
+		if !strings.HasPrefix(path, "/") || strings.IndexFunc(path, unicode.IsSpace) >= 0 || validateConfigValue("path", path) != nil {
+			return nil, fmt.Errorf("failed to parse error page %q: path %q must start with '/' and must not contain whitespace, quotes, backslashes or '${'", rule, path)
+		}
+
+		documents[code] = path
+	}
+
+	var result []ErrorDocument
+	for code, path := range documents {
+		result = append(result, ErrorDocument{Code: code, Path: path})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Code < result[j].Code
+	})
+
+	return result, nil
+}
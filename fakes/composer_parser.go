@@ -0,0 +1,31 @@
+package fakes
+
+import "sync"
+
+type ComposerParser struct {
+	ParseRoadRunnerCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			WorkingDir string
+		}
+		Returns struct {
+			Required      bool
+			Version       string
+			VersionSource string
+			Err           error
+		}
+		Stub func(string) (bool, string, string, error)
+	}
+}
+
+func (f *ComposerParser) ParseRoadRunner(param1 string) (bool, string, string, error) {
+	f.ParseRoadRunnerCall.mutex.Lock()
+	defer f.ParseRoadRunnerCall.mutex.Unlock()
+	f.ParseRoadRunnerCall.CallCount++
+	f.ParseRoadRunnerCall.Receives.WorkingDir = param1
+	if f.ParseRoadRunnerCall.Stub != nil {
+		return f.ParseRoadRunnerCall.Stub(param1)
+	}
+	return f.ParseRoadRunnerCall.Returns.Required, f.ParseRoadRunnerCall.Returns.Version, f.ParseRoadRunnerCall.Returns.VersionSource, f.ParseRoadRunnerCall.Returns.Err
+}
@@ -0,0 +1,32 @@
+package fakes
+
+import "sync"
+
+type ConfigChecker struct {
+	CheckCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			ServerRoot string
+			AppRoot    string
+			ConfigPath string
+		}
+		Returns struct {
+			Error error
+		}
+		Stub func(string, string, string) error
+	}
+}
+
+func (f *ConfigChecker) Check(param1 string, param2 string, param3 string) error {
+	f.CheckCall.mutex.Lock()
+	defer f.CheckCall.mutex.Unlock()
+	f.CheckCall.CallCount++
+	f.CheckCall.Receives.ServerRoot = param1
+	f.CheckCall.Receives.AppRoot = param2
+	f.CheckCall.Receives.ConfigPath = param3
+	if f.CheckCall.Stub != nil {
+		return f.CheckCall.Stub(param1, param2, param3)
+	}
+	return f.CheckCall.Returns.Error
+}
@@ -0,0 +1,34 @@
+package fakes
+
+import "sync"
+
+type ConfigLinter struct {
+	LintCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			ServerRoot string
+			AppRoot    string
+			ConfigPath string
+			Strict     bool
+		}
+		Returns struct {
+			Error error
+		}
+		Stub func(string, string, string, bool) error
+	}
+}
+
+func (f *ConfigLinter) Lint(param1 string, param2 string, param3 string, param4 bool) error {
+	f.LintCall.mutex.Lock()
+	defer f.LintCall.mutex.Unlock()
+	f.LintCall.CallCount++
+	f.LintCall.Receives.ServerRoot = param1
+	f.LintCall.Receives.AppRoot = param2
+	f.LintCall.Receives.ConfigPath = param3
+	f.LintCall.Receives.Strict = param4
+	if f.LintCall.Stub != nil {
+		return f.LintCall.Stub(param1, param2, param3, param4)
+	}
+	return f.LintCall.Returns.Error
+}
@@ -0,0 +1,28 @@
+package fakes
+
+import "sync"
+
+type Precompressor struct {
+	PrecompressCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Root string
+		}
+		Returns struct {
+			Error error
+		}
+		Stub func(string) error
+	}
+}
+
+func (f *Precompressor) Precompress(param1 string) error {
+	f.PrecompressCall.mutex.Lock()
+	defer f.PrecompressCall.mutex.Unlock()
+	f.PrecompressCall.CallCount++
+	f.PrecompressCall.Receives.Root = param1
+	if f.PrecompressCall.Stub != nil {
+		return f.PrecompressCall.Stub(param1)
+	}
+	return f.PrecompressCall.Returns.Error
+}
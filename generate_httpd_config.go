@@ -1,15 +1,50 @@
 package httpd
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"text/template"
+	"unicode"
 
+	"github.com/paketo-buildpacks/packit/v2/fs"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
 	"github.com/paketo-buildpacks/packit/v2/servicebindings"
 )
 
+// ConfigTemplateFile is the template in the application that is rendered
+// instead of the built-in httpd.conf when it exists.
+const ConfigTemplateFile = "httpd.conf.tmpl"
+
+// httpdConfFuncs are the helper functions available to the built-in
+// httpd.conf template and to ConfigTemplateFile.
+var httpdConfFuncs = template.FuncMap{
+	"join":      strings.Join,
+	"hasPrefix": strings.HasPrefix,
+}
+
+// defaultCompressionTypes are the MIME types compressed when
+// BP_WEB_SERVER_ENABLE_COMPRESSION is set without BP_WEB_SERVER_COMPRESSION_TYPES.
+var defaultCompressionTypes = []string{
+	"text/html",
+	"text/plain",
+	"text/css",
+	"text/xml",
+	"text/javascript",
+	"application/javascript",
+	"application/json",
+	"application/xml",
+	"application/manifest+json",
+	"image/svg+xml",
+	"font/ttf",
+	"font/otf",
+}
+
 //go:generate faux --interface BindingResolver --output fakes/binding_resolver.go
 type BindingResolver interface {
 	Resolve(typ, provider, platformDir string) ([]servicebindings.Binding, error)
@@ -30,16 +65,35 @@ func NewGenerateHTTPDConfig(bindingResolver BindingResolver, logger scribe.Emitt
 func (g GenerateHTTPDConfig) Generate(workingDir, platformPath string, buildEnvironment BuildEnvironment) error {
 	g.logger.Process("Generating httpd.conf")
 
-	t, err := template.New("httpd.conf").Parse(httpdConf)
+	t, err := template.New("httpd.conf").Funcs(httpdConfFuncs).Parse(httpdConf)
 	if err != nil {
 		return err
 	}
 
-	confFile, err := os.Create(filepath.Join(workingDir, "httpd.conf"))
+	templatePath := filepath.Join(workingDir, ConfigTemplateFile)
+	userTemplate, err := os.ReadFile(templatePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err == nil {
+		g.logger.Subprocess("Renders configuration from '%s'", ConfigTemplateFile)
+
+		// The built-in config stays available to the user template as
+		// {{template "httpd.conf" .}}.
+		t, err = t.New(ConfigTemplateFile).Parse(string(userTemplate))
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", ConfigTemplateFile, err)
+		}
+	}
+
+	err = validateBuildEnvironment(buildEnvironment)
 	if err != nil {
 		return err
 	}
 
+	siteRoot := webServerSiteRoot(workingDir, buildEnvironment.WebServerRoot)
+
 	if buildEnvironment.WebServerRoot == "" {
 		buildEnvironment.WebServerRoot = "${APP_ROOT}/public"
 	} else {
@@ -53,41 +107,327 @@ func (g GenerateHTTPDConfig) Generate(workingDir, platformPath string, buildEnvi
 
 	if buildEnvironment.WebServerPushStateEnabled {
 		g.logger.Subprocess("Adds configuration that enables push state")
+
+		fallback := strings.TrimPrefix(buildEnvironment.WebServerPushStateFallback, "/")
+		if fallback == "" {
+			fallback = "index.html"
+		} else {
+			g.logger.Subprocess("Adds configuration that falls back to '%s' for unknown paths", fallback)
+		}
+
+		if strings.IndexFunc(fallback, unicode.IsSpace) >= 0 {
+			return fmt.Errorf("failed: BP_WEB_SERVER_PUSH_STATE_FALLBACK %q must not contain whitespace", buildEnvironment.WebServerPushStateFallback)
+		}
+
+		if _, err := os.Stat(filepath.Join(siteRoot, fallback)); err != nil {
+			g.logger.Subprocess("WARNING: push state fallback '%s' does not exist in the web server root", fallback)
+		}
+		buildEnvironment.WebServerPushStateFallback = fallback
+
+		prefixes := strings.FieldsFunc(buildEnvironment.WebServerPushStateExclude, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})
+		for _, prefix := range prefixes {
+			prefix = "/" + strings.Trim(prefix, "/")
+			g.logger.Subprocess("Adds configuration that excludes '%s' from push state", prefix)
+			buildEnvironment.PushStateExcludePatterns = append(buildEnvironment.PushStateExcludePatterns, fmt.Sprintf("^%s(/|$)", regexp.QuoteMeta(prefix)))
+		}
 	}
 
 	if buildEnvironment.WebServerForceHTTPS {
 		g.logger.Subprocess("Adds configuration that forces https redirect")
 	}
 
-	bindings, err := g.bindingResolver.Resolve("htpasswd", "", platformPath)
+	if buildEnvironment.WebServerProxyUpstream != "" {
+		upstream := strings.TrimSuffix(buildEnvironment.WebServerProxyUpstream, "/")
+		if !strings.Contains(upstream, "://") {
+			upstream = fmt.Sprintf("http://%s", upstream)
+		}
+		g.logger.Subprocess("Adds configuration that proxies requests to '%s'", upstream)
+		buildEnvironment.WebServerProxyUpstream = upstream
+	}
+
+	if buildEnvironment.WebServerFastCGIAddress != "" {
+		if buildEnvironment.WebServerProxyUpstream != "" {
+			return fmt.Errorf("failed: BP_WEB_SERVER_PROXY_UPSTREAM and BP_WEB_SERVER_FASTCGI_ADDRESS cannot both be set")
+		}
+
+		address := fastCGIAddress(buildEnvironment.WebServerFastCGIAddress)
+		g.logger.Subprocess("Adds configuration that passes PHP requests to the FastCGI server at '%s'", address)
+		buildEnvironment.WebServerFastCGIAddress = address
+	}
+
+	if buildEnvironment.WebServerCompressionEnabled {
+		types := strings.FieldsFunc(buildEnvironment.WebServerCompressionTypes, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})
+		if len(types) == 0 {
+			types = defaultCompressionTypes
+		}
+		g.logger.Subprocess("Adds configuration that compresses responses of type %s", strings.Join(types, ", "))
+		buildEnvironment.WebServerCompressionTypes = strings.Join(types, " ")
+	}
+
+	accessLogName, accessLogFormatString, err := accessLogFormat(buildEnvironment.WebServerAccessLogFormat)
 	if err != nil {
 		return err
 	}
 
-	if len(bindings) > 1 {
-		return fmt.Errorf("failed: binding resolver found more than one binding of type 'htpasswd'")
+	switch {
+	case accessLogName == "off":
+		g.logger.Subprocess("Adds configuration that disables access logs")
+	case buildEnvironment.WebServerAccessLogFormat != "":
+		g.logger.Subprocess("Adds configuration that writes access logs in the %s format", accessLogName)
 	}
+	buildEnvironment.WebServerAccessLogFormat = accessLogName
+	buildEnvironment.AccessLogFormat = accessLogFormatString
 
-	if len(bindings) == 1 {
-		if _, ok := bindings[0].Entries[".htpasswd"]; !ok {
-			return fmt.Errorf("failed: binding of type 'htpasswd' does not contain required entry '.htpasswd'")
+	if buildEnvironment.WebServerHealthCheckPath != "" {
+		buildEnvironment.WebServerHealthCheckPath, err = endpointPath("BP_WEB_SERVER_HEALTH_CHECK_PATH", buildEnvironment.WebServerHealthCheckPath)
+		if err != nil {
+			return err
 		}
+		g.logger.Subprocess("Adds configuration that answers health checks at '%s'", buildEnvironment.WebServerHealthCheckPath)
+	}
 
-		g.logger.Subprocess("Adds configuration that configured basic authentication from service binding")
+	if buildEnvironment.WebServerStatusPath != "" {
+		buildEnvironment.WebServerStatusPath, err = endpointPath("BP_WEB_SERVER_STATUS_PATH", buildEnvironment.WebServerStatusPath)
+		if err != nil {
+			return err
+		}
 
-		buildEnvironment.BasicAuthFile = filepath.Join(bindings[0].Path, ".htpasswd")
+		if buildEnvironment.WebServerStatusPath == buildEnvironment.WebServerHealthCheckPath {
+			return fmt.Errorf("failed: BP_WEB_SERVER_HEALTH_CHECK_PATH and BP_WEB_SERVER_STATUS_PATH cannot both be '%s'", buildEnvironment.WebServerStatusPath)
+		}
+
+		allow, err := statusAllow(buildEnvironment.WebServerStatusAllow)
+		if err != nil {
+			return err
+		}
+
+		g.logger.Subprocess("Adds configuration that serves the server status at '%s' to %s", buildEnvironment.WebServerStatusPath, strings.Join(allow, ", "))
+		buildEnvironment.WebServerStatusAllow = strings.Join(allow, " ")
+		buildEnvironment.StatusPathPattern = statusPathPattern(buildEnvironment.WebServerStatusPath)
+	} else if buildEnvironment.WebServerStatusAllow != "" {
+		g.logger.Subprocess("WARNING: BP_WEB_SERVER_STATUS_ALLOW is ignored because BP_WEB_SERVER_STATUS_PATH is not set")
 	}
 
-	g.logger.Break()
+	buildEnvironment.SecurityHeaders, err = securityHeaders(buildEnvironment)
+	if err != nil {
+		return err
+	}
+
+	for _, header := range buildEnvironment.SecurityHeaders {
+		g.logger.Subprocess("Adds configuration that sets the %s header", header.Name)
+	}
+
+	if buildEnvironment.WebServerStrictTransportSecurity != "" && !buildEnvironment.WebServerForceHTTPS {
+		g.logger.Subprocess("WARNING: BP_WEB_SERVER_STRICT_TRANSPORT_SECURITY is ignored because BP_WEB_SERVER_FORCE_HTTPS is not set")
+	}
+
+	buildEnvironment.CachePolicies, err = parseCachePolicies(filepath.Join(workingDir, "web-server.toml"), buildEnvironment.WebServerCachePolicy)
+	if err != nil {
+		return err
+	}
+
+	for _, policy := range buildEnvironment.CachePolicies {
+		g.logger.Subprocess("Adds configuration that sets Cache-Control '%s' for %s '%s'", policy.CacheControl, policy.Section, policy.Pattern)
+	}
+
+	redirects, redirectErrorDocuments, redirectWarnings, err := parseRedirectsFile(filepath.Join(siteRoot, "_redirects"))
+	if err != nil {
+		return err
+	}
 
-	err = t.Execute(confFile, buildEnvironment)
+	headerRules, headerWarnings, err := parseHeadersFile(filepath.Join(siteRoot, "_headers"))
 	if err != nil {
 		return err
 	}
 
-	err = confFile.Close()
+	if len(redirects) > 0 {
+		g.logger.Subprocess("Adds configuration for %d rules from _redirects", len(redirects))
+	}
+
+	if len(headerRules) > 0 {
+		g.logger.Subprocess("Adds configuration for %d paths from _headers", len(headerRules))
+	}
+
+	for _, warning := range append(redirectWarnings, headerWarnings...) {
+		g.logger.Subprocess("WARNING: %s", warning)
+	}
+
+	for _, rule := range redirects {
+		if strings.HasPrefix(rule.Flags, "P,") {
+			buildEnvironment.RedirectsProxy = true
+		}
+	}
+
+	// Netlify does not serve the _redirects and _headers files, so they are
+	// not served here either.
+	for _, name := range []string{"_redirects", "_headers"} {
+		exists, err := fs.Exists(filepath.Join(siteRoot, name))
+		if err != nil {
+			return err
+		}
+
+		buildEnvironment.RuleFilesPresent = buildEnvironment.RuleFilesPresent || exists
+	}
+
+	buildEnvironment.Redirects = redirects
+	buildEnvironment.RedirectErrorDocuments = redirectErrorDocuments
+	buildEnvironment.HeaderRules = headerRules
+
+	buildEnvironment.ErrorDocuments, err = errorDocuments(siteRoot, buildEnvironment.WebServerErrorPages, buildEnvironment.WebServerPushStateEnabled)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	for _, document := range buildEnvironment.ErrorDocuments {
+		g.logger.Subprocess("Adds configuration that serves '%s' for status %d", document.Path, document.Code)
+	}
+
+	for i := len(buildEnvironment.RedirectErrorDocuments) - 1; i >= 0; i-- {
+		document := buildEnvironment.RedirectErrorDocuments[i]
+		g.logger.Subprocess("Adds configuration that serves '%s' for status %d on '%s'", document.Path, document.Code, document.Pattern)
+	}
+
+	if !buildEnvironment.BindingsResolved {
+		buildEnvironment, err = resolveBindings(g.bindingResolver, workingDir, platformPath, buildEnvironment)
+		if err != nil {
+			return err
+		}
+	}
+
+	if buildEnvironment.TLSCertificateFile != "" {
+		if buildEnvironment.WebServerTLSPort == "" {
+			buildEnvironment.WebServerTLSPort = "8443"
+		}
+
+		port, err := strconv.Atoi(buildEnvironment.WebServerTLSPort)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("failed: BP_WEB_SERVER_TLS_PORT '%s' is not a valid port", buildEnvironment.WebServerTLSPort)
+		}
+
+		g.logger.Subprocess("Adds configuration that terminates TLS on port %d from service binding", port)
+	}
+
+	for _, include := range buildEnvironment.ConfigIncludes {
+		g.logger.Subprocess("Adds configuration that includes '%s'", include)
+	}
+
+	if buildEnvironment.BasicAuthFile != "" {
+		g.logger.Subprocess("Adds configuration that configured basic authentication from service binding")
+	}
+
+	for _, location := range buildEnvironment.BasicAuthLocations {
+		g.logger.Subprocess("Adds configuration that configured basic authentication for '%s' from service binding", location.Path)
+	}
+
+	g.logger.Break()
+
+	buffer := bytes.NewBuffer(nil)
+	err = t.Execute(buffer, buildEnvironment)
+	if err != nil {
+		return fmt.Errorf("failed to render %s: %w", t.Name(), err)
+	}
+
+	return os.WriteFile(filepath.Join(workingDir, "httpd.conf"), buffer.Bytes(), 0644)
+}
+
+// resolveBindings sets the files that the httpd.conf takes from the tls,
+// httpd-config and htpasswd service bindings in platformPath.
+func resolveBindings(bindingResolver BindingResolver, workingDir, platformPath string, buildEnvironment BuildEnvironment) (BuildEnvironment, error) {
+	tlsBindings, err := bindingResolver.Resolve("tls", "", platformPath)
+	if err != nil {
+		return BuildEnvironment{}, err
+	}
+
+	if len(tlsBindings) > 1 {
+		return BuildEnvironment{}, fmt.Errorf("failed: binding resolver found more than one binding of type 'tls'")
+	}
+
+	if len(tlsBindings) == 1 {
+		for _, entry := range []string{"tls.crt", "tls.key"} {
+			if _, ok := tlsBindings[0].Entries[entry]; !ok {
+				return BuildEnvironment{}, fmt.Errorf("failed: binding of type 'tls' does not contain required entry '%s'", entry)
+			}
+		}
+
+		err = validateConfigValue(fmt.Sprintf("path of binding '%s'", tlsBindings[0].Name), tlsBindings[0].Path)
+		if err != nil {
+			return BuildEnvironment{}, err
+		}
+
+		buildEnvironment.TLSCertificateFile = filepath.Join(tlsBindings[0].Path, "tls.crt")
+		buildEnvironment.TLSKeyFile = filepath.Join(tlsBindings[0].Path, "tls.key")
+		if _, ok := tlsBindings[0].Entries["ca.crt"]; ok {
+			buildEnvironment.TLSCAFile = filepath.Join(tlsBindings[0].Path, "ca.crt")
+		}
+	}
+
+	configBindings, err := bindingResolver.Resolve("httpd-config", "", platformPath)
+	if err != nil {
+		return BuildEnvironment{}, err
+	}
+
+	buildEnvironment.ConfigIncludes, err = configIncludes(workingDir, configBindings)
+	if err != nil {
+		return BuildEnvironment{}, err
+	}
+
+	bindings, err := bindingResolver.Resolve("htpasswd", "", platformPath)
+	if err != nil {
+		return BuildEnvironment{}, err
+	}
+
+	locations, err := basicAuthLocations(bindings)
+	if err != nil {
+		return BuildEnvironment{}, err
+	}
+
+	for _, location := range locations {
+		if location.Path == "" {
+			buildEnvironment.BasicAuthFile = location.File
+			buildEnvironment.BasicAuthRealm = location.Realm
+			continue
+		}
+
+		buildEnvironment.BasicAuthLocations = append(buildEnvironment.BasicAuthLocations, location)
+	}
+
+	buildEnvironment.BindingsResolved = true
+
+	return buildEnvironment, nil
+}
+
+// fastCGIAddress converts a unix socket path, a port, or a host and port into
+// the address format expected by mod_proxy_fcgi handlers.
+func fastCGIAddress(address string) string {
+	switch {
+	case strings.HasPrefix(address, "unix:"):
+		address = strings.TrimPrefix(address, "unix:")
+		fallthrough
+	case filepath.IsAbs(address):
+		return fmt.Sprintf("unix:%s|fcgi://localhost", address)
+	case strings.HasPrefix(address, "fcgi://"):
+		return address
+	case !strings.Contains(address, ":"):
+		return fmt.Sprintf("fcgi://127.0.0.1:%s", address)
+	default:
+		return fmt.Sprintf("fcgi://%s", address)
+	}
+}
+
+// webServerSiteRoot returns the directory on disk that is served as the web
+// server root.
+func webServerSiteRoot(workingDir, webServerRoot string) string {
+	if webServerRoot == "" {
+		webServerRoot = "public"
+	}
+
+	if !filepath.IsAbs(webServerRoot) {
+		webServerRoot = filepath.Join(workingDir, webServerRoot)
+	}
+
+	return webServerRoot
 }
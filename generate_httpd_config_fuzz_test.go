@@ -0,0 +1,138 @@
+package httpd_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paketo-buildpacks/httpd"
+	"github.com/paketo-buildpacks/httpd/fakes"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
+)
+
+func FuzzGenerateHTTPDConfigWebServerRoot(f *testing.F) {
+	for _, seed := range []string{"public", "/srv/www", "dist\"\nLoadModule cgi_module modules/mod_cgi.so\n#", "${HOME}", "a\\\"b", "htdocs\r\nListen 22"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, root string) {
+		if root == "" {
+			t.Skip()
+		}
+
+		fuzzGenerateHTTPDConfig(t,
+			&fakes.BindingResolver{}, httpd.BuildEnvironment{WebServerRoot: "fuzz-root"},
+			&fakes.BindingResolver{}, httpd.BuildEnvironment{WebServerRoot: root},
+		)
+	})
+}
+
+func FuzzGenerateHTTPDConfigProxyUpstream(f *testing.F) {
+	for _, seed := range []string{"localhost:3000", "https://api.example.com/", "backend\"\nProxyPass / http://evil/\n#", "${UPSTREAM}"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, upstream string) {
+		if upstream == "" {
+			t.Skip()
+		}
+
+		fuzzGenerateHTTPDConfig(t,
+			&fakes.BindingResolver{}, httpd.BuildEnvironment{WebServerProxyUpstream: "fuzz-upstream"},
+			&fakes.BindingResolver{}, httpd.BuildEnvironment{WebServerProxyUpstream: upstream},
+		)
+	})
+}
+
+func FuzzGenerateHTTPDConfigBasicAuthBindingPath(f *testing.F) {
+	for _, seed := range []string{"/platform/bindings/auth", "/bindings/a\"\nRequire all granted\n#", "/bindings/${HOME}"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, path string) {
+		resolver := func(path string) *fakes.BindingResolver {
+			bindingResolver := &fakes.BindingResolver{}
+			bindingResolver.ResolveCall.Stub = func(typ, provider, platformDir string) ([]servicebindings.Binding, error) {
+				if typ != "htpasswd" {
+					return nil, nil
+				}
+
+				return []servicebindings.Binding{
+					{
+						Name: "auth",
+						Type: "htpasswd",
+						Path: path,
+						Entries: map[string]*servicebindings.Entry{
+							".htpasswd": servicebindings.NewEntry("some-path"),
+						},
+					},
+				}, nil
+			}
+			return bindingResolver
+		}
+
+		fuzzGenerateHTTPDConfig(t,
+			resolver("/platform/bindings/fuzz-path"), httpd.BuildEnvironment{},
+			resolver(path), httpd.BuildEnvironment{},
+		)
+	})
+}
+
+// fuzzGenerateHTTPDConfig generates a config from a benign build environment
+// and from a fuzzed one, and fails if the fuzzed value was accepted but
+// changed the structure of the config.
+func fuzzGenerateHTTPDConfig(t *testing.T, benignResolver *fakes.BindingResolver, benign httpd.BuildEnvironment, fuzzedResolver *fakes.BindingResolver, fuzzed httpd.BuildEnvironment) {
+	generate := func(bindingResolver *fakes.BindingResolver, buildEnvironment httpd.BuildEnvironment) (string, error) {
+		workingDir := t.TempDir()
+		err := httpd.NewGenerateHTTPDConfig(bindingResolver, scribe.NewEmitter(io.Discard)).Generate(workingDir, "platform", buildEnvironment)
+		if err != nil {
+			return "", err
+		}
+
+		contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return string(contents), nil
+	}
+
+	expected, err := generate(benignResolver, benign)
+	if err != nil {
+		t.Fatalf("failed to generate the benign config: %s", err)
+	}
+
+	actual, err := generate(fuzzedResolver, fuzzed)
+	if err != nil {
+		if !strings.HasPrefix(err.Error(), "failed") {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return
+	}
+
+	if got, want := directives(actual), directives(expected); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("fuzzed value changed the directives of the config:\n%s", actual)
+	}
+
+	if strings.Count(actual, `"`) != strings.Count(expected, `"`) {
+		t.Fatalf("fuzzed value changed the quoting of the config:\n%s", actual)
+	}
+
+	if strings.Count(actual, "${") > strings.Count(expected, "${") {
+		t.Fatalf("fuzzed value added a variable to the config:\n%s", actual)
+	}
+}
+
+func directives(conf string) []string {
+	var names []string
+	for _, line := range strings.Split(conf, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 {
+			names = append(names, fields[0])
+		}
+	}
+	return names
+}
@@ -2,9 +2,13 @@ package httpd_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/paketo-buildpacks/httpd"
@@ -72,6 +76,8 @@ LoadModule mime_module modules/mod_mime.so
 LoadModule dir_module modules/mod_dir.so
 LoadModule authz_core_module modules/mod_authz_core.so
 LoadModule unixd_module modules/mod_unixd.so
+LoadModule headers_module modules/mod_headers.so
+LoadModule unique_id_module modules/mod_unique_id.so
 
 TypesConfig conf/mime.types
 
@@ -79,6 +85,11 @@ PidFile /tmp/httpd.pid
 
 User nobody
 
+ServerLimit ${HTTPD_SERVER_LIMIT}
+ThreadsPerChild ${HTTPD_THREADS_PER_CHILD}
+MaxRequestWorkers ${HTTPD_MAX_REQUEST_WORKERS}
+MaxConnectionsPerChild ${HTTPD_MAX_CONNECTIONS_PER_CHILD}
+
 Listen "${PORT}"
 
 DocumentRoot "${APP_ROOT}/public"
@@ -86,10 +97,15 @@ DocumentRoot "${APP_ROOT}/public"
 DirectoryIndex index.html
 
 ErrorLog /proc/self/fd/2
+ErrorLogFormat "[%{u}t] [%-m:%l] [pid %P:tid %T] [request_id\ %{X-Request-ID}i] [unique_id\ %{UNIQUE_ID}e] %7F: %E: [client\ %a] %M% ,\ referer\ %{Referer}i"
 
-LogFormat "%h %l %u %t \"%r\" %>s %b" common
+LogFormat "%h %l %u %t \"%r\" %>s %b \"%{X-Request-ID}o\"" common
 CustomLog /proc/self/fd/1 common
 
+RequestHeader setifempty X-Request-ID "%{UNIQUE_ID}e"
+Header always set X-Request-ID "expr=%{req:X-Request-ID}"
+Header always set X-Request-ID "%{UNIQUE_ID}e" "expr=-z %{req:X-Request-ID}"
+
 <Directory />
   AllowOverride None
   Require all denied
@@ -129,6 +145,8 @@ LoadModule mime_module modules/mod_mime.so
 LoadModule dir_module modules/mod_dir.so
 LoadModule authz_core_module modules/mod_authz_core.so
 LoadModule unixd_module modules/mod_unixd.so
+LoadModule headers_module modules/mod_headers.so
+LoadModule unique_id_module modules/mod_unique_id.so
 
 TypesConfig conf/mime.types
 
@@ -136,6 +154,11 @@ PidFile /tmp/httpd.pid
 
 User nobody
 
+ServerLimit ${HTTPD_SERVER_LIMIT}
+ThreadsPerChild ${HTTPD_THREADS_PER_CHILD}
+MaxRequestWorkers ${HTTPD_MAX_REQUEST_WORKERS}
+MaxConnectionsPerChild ${HTTPD_MAX_CONNECTIONS_PER_CHILD}
+
 Listen "${PORT}"
 
 DocumentRoot "${APP_ROOT}/htdocs"
@@ -143,10 +166,15 @@ DocumentRoot "${APP_ROOT}/htdocs"
 DirectoryIndex index.html
 
 ErrorLog /proc/self/fd/2
+ErrorLogFormat "[%{u}t] [%-m:%l] [pid %P:tid %T] [request_id\ %{X-Request-ID}i] [unique_id\ %{UNIQUE_ID}e] %7F: %E: [client\ %a] %M% ,\ referer\ %{Referer}i"
 
-LogFormat "%h %l %u %t \"%r\" %>s %b" common
+LogFormat "%h %l %u %t \"%r\" %>s %b \"%{X-Request-ID}o\"" common
 CustomLog /proc/self/fd/1 common
 
+RequestHeader setifempty X-Request-ID "%{UNIQUE_ID}e"
+Header always set X-Request-ID "expr=%{req:X-Request-ID}"
+Header always set X-Request-ID "%{UNIQUE_ID}e" "expr=-z %{req:X-Request-ID}"
+
 <Directory />
   AllowOverride None
   Require all denied
@@ -186,6 +214,8 @@ LoadModule mime_module modules/mod_mime.so
 LoadModule dir_module modules/mod_dir.so
 LoadModule authz_core_module modules/mod_authz_core.so
 LoadModule unixd_module modules/mod_unixd.so
+LoadModule headers_module modules/mod_headers.so
+LoadModule unique_id_module modules/mod_unique_id.so
 
 TypesConfig conf/mime.types
 
@@ -193,6 +223,11 @@ PidFile /tmp/httpd.pid
 
 User nobody
 
+ServerLimit ${HTTPD_SERVER_LIMIT}
+ThreadsPerChild ${HTTPD_THREADS_PER_CHILD}
+MaxRequestWorkers ${HTTPD_MAX_REQUEST_WORKERS}
+MaxConnectionsPerChild ${HTTPD_MAX_CONNECTIONS_PER_CHILD}
+
 Listen "${PORT}"
 
 DocumentRoot "/absolute/path"
@@ -200,10 +235,15 @@ DocumentRoot "/absolute/path"
 DirectoryIndex index.html
 
 ErrorLog /proc/self/fd/2
+ErrorLogFormat "[%{u}t] [%-m:%l] [pid %P:tid %T] [request_id\ %{X-Request-ID}i] [unique_id\ %{UNIQUE_ID}e] %7F: %E: [client\ %a] %M% ,\ referer\ %{Referer}i"
 
-LogFormat "%h %l %u %t \"%r\" %>s %b" common
+LogFormat "%h %l %u %t \"%r\" %>s %b \"%{X-Request-ID}o\"" common
 CustomLog /proc/self/fd/1 common
 
+RequestHeader setifempty X-Request-ID "%{UNIQUE_ID}e"
+Header always set X-Request-ID "expr=%{req:X-Request-ID}"
+Header always set X-Request-ID "%{UNIQUE_ID}e" "expr=-z %{req:X-Request-ID}"
+
 <Directory />
   AllowOverride None
   Require all denied
@@ -244,6 +284,8 @@ LoadModule mime_module modules/mod_mime.so
 LoadModule dir_module modules/mod_dir.so
 LoadModule authz_core_module modules/mod_authz_core.so
 LoadModule unixd_module modules/mod_unixd.so
+LoadModule headers_module modules/mod_headers.so
+LoadModule unique_id_module modules/mod_unique_id.so
 LoadModule rewrite_module modules/mod_rewrite.so
 LoadModule autoindex_module modules/mod_autoindex.so
 
@@ -253,6 +295,11 @@ PidFile /tmp/httpd.pid
 
 User nobody
 
+ServerLimit ${HTTPD_SERVER_LIMIT}
+ThreadsPerChild ${HTTPD_THREADS_PER_CHILD}
+MaxRequestWorkers ${HTTPD_MAX_REQUEST_WORKERS}
+MaxConnectionsPerChild ${HTTPD_MAX_CONNECTIONS_PER_CHILD}
+
 Listen "${PORT}"
 
 DocumentRoot "${APP_ROOT}/public"
@@ -260,10 +307,15 @@ DocumentRoot "${APP_ROOT}/public"
 DirectoryIndex index.html
 
 ErrorLog /proc/self/fd/2
+ErrorLogFormat "[%{u}t] [%-m:%l] [pid %P:tid %T] [request_id\ %{X-Request-ID}i] [unique_id\ %{UNIQUE_ID}e] %7F: %E: [client\ %a] %M% ,\ referer\ %{Referer}i"
 
-LogFormat "%h %l %u %t \"%r\" %>s %b" common
+LogFormat "%h %l %u %t \"%r\" %>s %b \"%{X-Request-ID}o\"" common
 CustomLog /proc/self/fd/1 common
 
+RequestHeader setifempty X-Request-ID "%{UNIQUE_ID}e"
+Header always set X-Request-ID "expr=%{req:X-Request-ID}"
+Header always set X-Request-ID "%{UNIQUE_ID}e" "expr=-z %{req:X-Request-ID}"
+
 <Directory />
   AllowOverride None
   Require all denied
@@ -284,6 +336,312 @@ CustomLog /proc/self/fd/1 common
   Require all denied
 </Files>`), string(contents))
 			})
+
+			context("when BP_WEB_SERVER_PUSH_STATE_FALLBACK is set", func() {
+				it.Before(func() {
+					Expect(os.MkdirAll(filepath.Join(workingDir, "public"), os.ModePerm)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(workingDir, "public", "200.html"), nil, 0600)).To(Succeed())
+				})
+
+				it("rewrites unknown paths to the fallback file", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+						WebServerPushStateEnabled:  true,
+						WebServerPushStateFallback: "/200.html",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(buffer.String()).To(ContainSubstring("Adds configuration that falls back to '200.html' for unknown paths"))
+					Expect(buffer.String()).NotTo(ContainSubstring("WARNING"))
+
+					contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(contents)).To(ContainSubstring(`
+  RewriteCond %{REQUEST_FILENAME} !-f
+  RewriteCond %{REQUEST_FILENAME} !-d
+  RewriteRule (.*) 200.html
+</Directory>`))
+				})
+
+				context("when the fallback file does not exist", func() {
+					it("warns about the missing file", func() {
+						err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+							WebServerPushStateEnabled:  true,
+							WebServerPushStateFallback: "app.html",
+						})
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(buffer.String()).To(ContainSubstring("WARNING: push state fallback 'app.html' does not exist in the web server root"))
+					})
+				})
+			})
+
+			context("when BP_WEB_SERVER_PUSH_STATE_EXCLUDE is set", func() {
+				it("does not rewrite paths under the excluded prefixes", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+						WebServerPushStateEnabled: true,
+						WebServerPushStateExclude: "/api/, health.check,/v1/graphql",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(buffer.String()).To(ContainSubstring("Adds configuration that excludes '/api' from push state"))
+					Expect(buffer.String()).To(ContainSubstring("Adds configuration that excludes '/health.check' from push state"))
+					Expect(buffer.String()).To(ContainSubstring("Adds configuration that excludes '/v1/graphql' from push state"))
+
+					contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(contents)).To(ContainSubstring(`
+  RewriteCond %{REQUEST_FILENAME} !-f
+  RewriteCond %{REQUEST_FILENAME} !-d
+  RewriteCond %{REQUEST_URI} !^/api(/|$)
+  RewriteCond %{REQUEST_URI} !^/health\.check(/|$)
+  RewriteCond %{REQUEST_URI} !^/v1/graphql(/|$)
+  RewriteRule (.*) index.html
+</Directory>`))
+				})
+			})
+
+			context("failure cases", func() {
+				context("when the fallback contains whitespace", func() {
+					it("returns an error", func() {
+						err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+							WebServerPushStateEnabled:  true,
+							WebServerPushStateFallback: "my app.html",
+						})
+						Expect(err).To(MatchError(`failed: BP_WEB_SERVER_PUSH_STATE_FALLBACK "my app.html" must not contain whitespace`))
+					})
+				})
+
+				context("when an excluded prefix contains a quote", func() {
+					it("returns an error", func() {
+						err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+							WebServerPushStateEnabled: true,
+							WebServerPushStateExclude: `/api"`,
+						})
+						Expect(err).To(MatchError(ContainSubstring("BP_WEB_SERVER_PUSH_STATE_EXCLUDE")))
+					})
+				})
+			})
+		})
+
+		it("takes or generates a request ID and echoes it in the response and logs", func() {
+			err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+				WebServerAccessLogFormat: "json",
+				WebServerProxyUpstream:   "localhost:3000",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(contents)).To(ContainSubstring("LoadModule unique_id_module modules/mod_unique_id.so\n"))
+			Expect(string(contents)).To(ContainSubstring(`[request_id\ %{X-Request-ID}i]`))
+			Expect(string(contents)).To(ContainSubstring(`\"request_id\":\"%{X-Request-ID}o\"`))
+			Expect(string(contents)).To(ContainSubstring(`
+RequestHeader setifempty X-Request-ID "%{UNIQUE_ID}e"
+Header always set X-Request-ID "expr=%{req:X-Request-ID}"
+Header always set X-Request-ID "%{UNIQUE_ID}e" "expr=-z %{req:X-Request-ID}"
+
+ProxyPreserveHost On
+`))
+		})
+
+		context("when a request is rejected before the request ID header is added", func() {
+			// RequestHeader runs in the fixups phase, which a 401 from basic
+			// authentication or a 301 from the https redirect never reaches.
+			var expectRequestIDFallback = func(contents string) {
+				Expect(contents).To(ContainSubstring(`
+Header always set X-Request-ID "expr=%{req:X-Request-ID}"
+Header always set X-Request-ID "%{UNIQUE_ID}e" "expr=-z %{req:X-Request-ID}"
+`))
+				Expect(contents).To(ContainSubstring(`[request_id\ %{X-Request-ID}i] [unique_id\ %{UNIQUE_ID}e]`))
+				Expect(contents).To(ContainSubstring(`\"request_id\":\"%{X-Request-ID}o\"`))
+				Expect(strings.Index(contents, `"%{UNIQUE_ID}e" "expr=-z`)).To(BeNumerically("<", strings.Index(contents, "<Directory")))
+			}
+
+			it("sets the generated request ID on a 401 from basic authentication", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+					WebServerAccessLogFormat: "json",
+					BasicAuthFile:            "/bindings/auth/.htpasswd",
+					BindingsResolved:         true,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(ContainSubstring("  Require valid-user\n"))
+				expectRequestIDFallback(string(contents))
+			})
+
+			it("sets the generated request ID on a 301 from the https redirect", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+					WebServerAccessLogFormat: "json",
+					WebServerForceHTTPS:      true,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(ContainSubstring("[L,R=301]"))
+				expectRequestIDFallback(string(contents))
+			})
+		})
+
+		context("when BP_WEB_SERVER_ACCESS_LOG_FORMAT is set", func() {
+			it("writes combined access logs", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerAccessLogFormat: "combined"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration that writes access logs in the combined format"))
+
+				contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(ContainSubstring(`ErrorLog /proc/self/fd/2
+ErrorLogFormat "[%{u}t] [%-m:%l] [pid %P:tid %T] [request_id\ %{X-Request-ID}i] [unique_id\ %{UNIQUE_ID}e] %7F: %E: [client\ %a] %M% ,\ referer\ %{Referer}i"
+
+LogFormat "%h %l %u %t \"%r\" %>s %b \"%{Referer}i\" \"%{User-Agent}i\" \"%{X-Request-ID}o\"" combined
+CustomLog /proc/self/fd/1 combined
+`))
+			})
+
+			it("writes JSON access logs", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerAccessLogFormat: "JSON"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration that writes access logs in the json format"))
+
+				contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(ContainSubstring(`ErrorLog /proc/self/fd/2
+ErrorLogFormat "[%{u}t] [%-m:%l] [pid %P:tid %T] [request_id\ %{X-Request-ID}i] [unique_id\ %{UNIQUE_ID}e] %7F: %E: [client\ %a] %M% ,\ referer\ %{Referer}i"
+
+LogFormat "{\"timestamp\":\"%{%Y-%m-%dT%H:%M:%S%z}t\",\"method\":\"%m\",\"path\":\"%{ACCESS_LOG_PATH}e\",\"status\":%>s,\"bytes\":%B,\"duration_us\":%D,\"user_agent\":\"%{ACCESS_LOG_USER_AGENT}e\",\"forwarded_for\":\"%{ACCESS_LOG_FORWARDED_FOR}e\",\"request_id\":\"%{X-Request-ID}o\"}" json
+CustomLog /proc/self/fd/1 json
+
+RewriteEngine On
+RewriteOptions InheritDown
+RewriteCond %{THE_REQUEST} "^\S+ ([\x21-\x3e\x40-\x7e]+)[? ]"
+RewriteRule ^ - [E=ACCESS_LOG_PATH:%1]
+SetEnvIf User-Agent "^([\x20-\x7e]+)$" ACCESS_LOG_USER_AGENT=$1
+SetEnvIf X-Forwarded-For "^([\x20-\x7e]+)$" ACCESS_LOG_FORWARDED_FOR=$1
+`))
+				Expect(string(contents)).To(ContainSubstring("LoadModule rewrite_module modules/mod_rewrite.so\n"))
+				Expect(string(contents)).To(ContainSubstring("LoadModule setenvif_module modules/mod_setenvif.so\n"))
+			})
+
+			context("when a request contains bytes outside of printable ASCII", func() {
+				// escape writes a value the way mod_log_config does, which
+				// is not valid JSON for bytes outside of printable ASCII.
+				var escape = func(value string) string {
+					var escaped strings.Builder
+					for _, b := range []byte(value) {
+						switch {
+						case b == '"' || b == '\\':
+							escaped.WriteString(`\` + string(b))
+						case b < 0x20 || b > 0x7e:
+							fmt.Fprintf(&escaped, `\x%02x`, b)
+						default:
+							escaped.WriteByte(b)
+						}
+					}
+					return escaped.String()
+				}
+
+				it("logs valid JSON with the undecoded path", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerAccessLogFormat: "json"})
+					Expect(err).NotTo(HaveOccurred())
+
+					contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).NotTo(HaveOccurred())
+
+					config := string(contents)
+					directive := func(pattern string) *regexp.Regexp {
+						matches := regexp.MustCompile(pattern).FindStringSubmatch(config)
+						Expect(matches).To(HaveLen(2), pattern)
+						return regexp.MustCompile(matches[1])
+					}
+
+					format := regexp.MustCompile(`(?m)^LogFormat "(.*)" json$`).FindStringSubmatch(config)
+					Expect(format).To(HaveLen(2))
+
+					theRequest := directive(`RewriteCond %\{THE_REQUEST\} "([^"]+)"`)
+					userAgent := directive(`SetEnvIf User-Agent "([^"]+)"`)
+					forwardedFor := directive(`SetEnvIf X-Forwarded-For "([^"]+)"`)
+
+					for _, request := range []struct {
+						line         string
+						userAgent    string
+						forwardedFor string
+						path         string
+					}{
+						{line: "GET /caf%C3%A9?q=th%C3%A9 HTTP/1.1", userAgent: "curl/8.5.0", forwardedFor: "203.0.113.7", path: "/caf%C3%A9"},
+						{line: "GET /café HTTP/1.1", userAgent: "Navigateur/1.0 (Café)", path: "-"},
+						{line: "GET / HTTP/1.1", userAgent: "curl/\"8.5.0\" \\", path: "/"},
+					} {
+						environment := func(pattern *regexp.Regexp, value string) string {
+							matches := pattern.FindStringSubmatch(value)
+							if matches == nil {
+								return "-"
+							}
+							return escape(matches[1])
+						}
+
+						values := map[string]string{
+							"%{%Y-%m-%dT%H:%M:%S%z}t":      "2024-01-02T15:04:05+0000",
+							"%m":                           "GET",
+							"%{ACCESS_LOG_PATH}e":          environment(theRequest, request.line),
+							"%>s":                          "404",
+							"%B":                           "196",
+							"%D":                           "532",
+							"%{ACCESS_LOG_USER_AGENT}e":    environment(userAgent, request.userAgent),
+							"%{ACCESS_LOG_FORWARDED_FOR}e": environment(forwardedFor, request.forwardedFor),
+							"%{X-Request-ID}o":             "ZZ2_Mx8AAQEAAAAHnU0AAAAA",
+						}
+
+						// httpd unescapes the quotes of the LogFormat argument
+						// before the format items are replaced.
+						line := regexp.MustCompile(`%(\{[^}]*\})?>?[A-Za-z]`).ReplaceAllStringFunc(strings.ReplaceAll(format[1], `\"`, `"`), func(item string) string {
+							value, ok := values[item]
+							Expect(ok).To(BeTrue(), item)
+							return value
+						})
+
+						Expect(json.Valid([]byte(line))).To(BeTrue(), line)
+
+						var entry map[string]interface{}
+						Expect(json.Unmarshal([]byte(line), &entry)).To(Succeed())
+						Expect(entry["path"]).To(Equal(request.path))
+					}
+				})
+			})
+
+			it("disables access logs", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerAccessLogFormat: "off"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration that disables access logs"))
+
+				contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(ContainSubstring(`ErrorLog /proc/self/fd/2
+ErrorLogFormat "[%{u}t] [%-m:%l] [pid %P:tid %T] [request_id\ %{X-Request-ID}i] [unique_id\ %{UNIQUE_ID}e] %7F: %E: [client\ %a] %M% ,\ referer\ %{Referer}i"
+
+RequestHeader setifempty X-Request-ID "%{UNIQUE_ID}e"`))
+				Expect(string(contents)).NotTo(ContainSubstring("CustomLog"))
+			})
+
+			context("when the format is not supported", func() {
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerAccessLogFormat: "logfmt"})
+					Expect(err).To(MatchError(`failed: BP_WEB_SERVER_ACCESS_LOG_FORMAT "logfmt" must be one of common, combined, json or off`))
+				})
+			})
 		})
 
 		context("when BP_WEB_SERVER_FORCE_HTTPS is set", func() {
@@ -310,6 +668,8 @@ LoadModule mime_module modules/mod_mime.so
 LoadModule dir_module modules/mod_dir.so
 LoadModule authz_core_module modules/mod_authz_core.so
 LoadModule unixd_module modules/mod_unixd.so
+LoadModule headers_module modules/mod_headers.so
+LoadModule unique_id_module modules/mod_unique_id.so
 LoadModule rewrite_module modules/mod_rewrite.so
 
 TypesConfig conf/mime.types
@@ -318,6 +678,11 @@ PidFile /tmp/httpd.pid
 
 User nobody
 
+ServerLimit ${HTTPD_SERVER_LIMIT}
+ThreadsPerChild ${HTTPD_THREADS_PER_CHILD}
+MaxRequestWorkers ${HTTPD_MAX_REQUEST_WORKERS}
+MaxConnectionsPerChild ${HTTPD_MAX_CONNECTIONS_PER_CHILD}
+
 Listen "${PORT}"
 
 DocumentRoot "${APP_ROOT}/public"
@@ -325,10 +690,15 @@ DocumentRoot "${APP_ROOT}/public"
 DirectoryIndex index.html
 
 ErrorLog /proc/self/fd/2
+ErrorLogFormat "[%{u}t] [%-m:%l] [pid %P:tid %T] [request_id\ %{X-Request-ID}i] [unique_id\ %{UNIQUE_ID}e] %7F: %E: [client\ %a] %M% ,\ referer\ %{Referer}i"
 
-LogFormat "%h %l %u %t \"%r\" %>s %b" common
+LogFormat "%h %l %u %t \"%r\" %>s %b \"%{X-Request-ID}o\"" common
 CustomLog /proc/self/fd/1 common
 
+RequestHeader setifempty X-Request-ID "%{UNIQUE_ID}e"
+Header always set X-Request-ID "expr=%{req:X-Request-ID}"
+Header always set X-Request-ID "%{UNIQUE_ID}e" "expr=-z %{req:X-Request-ID}"
+
 <Directory />
   AllowOverride None
   Require all denied
@@ -349,29 +719,12 @@ CustomLog /proc/self/fd/1 common
 			})
 		})
 
-		context("when the htpasswd service binding is set", func() {
-			it.Before(func() {
-				bindingResolver.ResolveCall.Returns.BindingSlice = []servicebindings.Binding{
-					{
-						Name: "first",
-						Type: "htpasswd",
-						Path: "some-binding-path",
-						Entries: map[string]*servicebindings.Entry{
-							".htpasswd": servicebindings.NewEntry("some-path"),
-						},
-					},
-				}
-			})
-
-			it("creates a config with that requires basic auth", func() {
-				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+		context("when BP_WEB_SERVER_PROXY_UPSTREAM is set", func() {
+			it("creates a config that proxies requests that are not static files", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerProxyUpstream: "127.0.0.1:8080/"})
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(bindingResolver.ResolveCall.Receives.Typ).To(Equal("htpasswd"))
-				Expect(bindingResolver.ResolveCall.Receives.Provider).To(Equal(""))
-				Expect(bindingResolver.ResolveCall.Receives.PlatformDir).To(Equal("platform"))
-
-				Expect(buffer.String()).To(ContainSubstring("Adds configuration that configured basic authentication from service binding"))
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration that proxies requests to 'http://127.0.0.1:8080'"))
 
 				contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
 				Expect(err).NotTo(HaveOccurred())
@@ -386,12 +739,11 @@ LoadModule mime_module modules/mod_mime.so
 LoadModule dir_module modules/mod_dir.so
 LoadModule authz_core_module modules/mod_authz_core.so
 LoadModule unixd_module modules/mod_unixd.so
-LoadModule authn_core_module modules/mod_authn_core.so
-LoadModule authn_file_module modules/mod_authn_file.so
-LoadModule authz_host_module modules/mod_authz_host.so
-LoadModule authz_user_module modules/mod_authz_user.so
-LoadModule access_compat_module modules/mod_access_compat.so
-LoadModule auth_basic_module modules/mod_auth_basic.so
+LoadModule headers_module modules/mod_headers.so
+LoadModule unique_id_module modules/mod_unique_id.so
+LoadModule rewrite_module modules/mod_rewrite.so
+LoadModule proxy_module modules/mod_proxy.so
+LoadModule proxy_http_module modules/mod_proxy_http.so
 
 TypesConfig conf/mime.types
 
@@ -399,6 +751,11 @@ PidFile /tmp/httpd.pid
 
 User nobody
 
+ServerLimit ${HTTPD_SERVER_LIMIT}
+ThreadsPerChild ${HTTPD_THREADS_PER_CHILD}
+MaxRequestWorkers ${HTTPD_MAX_REQUEST_WORKERS}
+MaxConnectionsPerChild ${HTTPD_MAX_CONNECTIONS_PER_CHILD}
+
 Listen "${PORT}"
 
 DocumentRoot "${APP_ROOT}/public"
@@ -406,24 +763,29 @@ DocumentRoot "${APP_ROOT}/public"
 DirectoryIndex index.html
 
 ErrorLog /proc/self/fd/2
+ErrorLogFormat "[%{u}t] [%-m:%l] [pid %P:tid %T] [request_id\ %{X-Request-ID}i] [unique_id\ %{UNIQUE_ID}e] %7F: %E: [client\ %a] %M% ,\ referer\ %{Referer}i"
 
-LogFormat "%h %l %u %t \"%r\" %>s %b" common
+LogFormat "%h %l %u %t \"%r\" %>s %b \"%{X-Request-ID}o\"" common
 CustomLog /proc/self/fd/1 common
 
+RequestHeader setifempty X-Request-ID "%{UNIQUE_ID}e"
+Header always set X-Request-ID "expr=%{req:X-Request-ID}"
+Header always set X-Request-ID "%{UNIQUE_ID}e" "expr=-z %{req:X-Request-ID}"
+
+ProxyPreserveHost On
+ProxyPassReverse "/" "http://127.0.0.1:8080/"
+
 <Directory />
   AllowOverride None
   Require all denied
 </Directory>
 
 <Directory "${APP_ROOT}/public">
-  Require valid-user
-
-  AuthType Basic
-  AuthName "Authentication Required"
-  AuthUserFile "some-binding-path/.htpasswd"
+  Require all granted
 
-  Order allow,deny
-  Allow from all
+  RewriteEngine On
+  RewriteCond %{REQUEST_FILENAME} !-f
+  RewriteRule ^ http://127.0.0.1:8080%{REQUEST_URI} [P,L]
 </Directory>
 
 <Files ".ht*">
@@ -432,70 +794,1770 @@ CustomLog /proc/self/fd/1 common
 			})
 		})
 
-		context("failure cases", func() {
-			context("when the config file cannot be created", func() {
-				it.Before(func() {
-					Expect(os.Chmod(workingDir, 0000)).To(Succeed())
-				})
+		context("when BP_WEB_SERVER_FASTCGI_ADDRESS is set", func() {
+			it("creates a config that passes PHP requests to the FastCGI server", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerFastCGIAddress: "9000"})
+				Expect(err).NotTo(HaveOccurred())
 
-				it.After(func() {
-					Expect(os.Chmod(workingDir, os.ModePerm)).To(Succeed())
-				})
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration that passes PHP requests to the FastCGI server at 'fcgi://127.0.0.1:9000'"))
 
-				it("returns an error", func() {
-					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
-					Expect(err).To(MatchError(ContainSubstring("permission denied")))
-				})
+				contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(Equal(`ServerRoot "${SERVER_ROOT}"
+
+ServerName "0.0.0.0"
+
+LoadModule mpm_event_module modules/mod_mpm_event.so
+LoadModule log_config_module modules/mod_log_config.so
+LoadModule mime_module modules/mod_mime.so
+LoadModule dir_module modules/mod_dir.so
+LoadModule authz_core_module modules/mod_authz_core.so
+LoadModule unixd_module modules/mod_unixd.so
+LoadModule headers_module modules/mod_headers.so
+LoadModule unique_id_module modules/mod_unique_id.so
+LoadModule proxy_module modules/mod_proxy.so
+LoadModule proxy_fcgi_module modules/mod_proxy_fcgi.so
+
+TypesConfig conf/mime.types
+
+PidFile /tmp/httpd.pid
+
+User nobody
+
+ServerLimit ${HTTPD_SERVER_LIMIT}
+ThreadsPerChild ${HTTPD_THREADS_PER_CHILD}
+MaxRequestWorkers ${HTTPD_MAX_REQUEST_WORKERS}
+MaxConnectionsPerChild ${HTTPD_MAX_CONNECTIONS_PER_CHILD}
+
+Listen "${PORT}"
+
+DocumentRoot "${APP_ROOT}/public"
+
+DirectoryIndex index.php index.html
+
+ErrorLog /proc/self/fd/2
+ErrorLogFormat "[%{u}t] [%-m:%l] [pid %P:tid %T] [request_id\ %{X-Request-ID}i] [unique_id\ %{UNIQUE_ID}e] %7F: %E: [client\ %a] %M% ,\ referer\ %{Referer}i"
+
+LogFormat "%h %l %u %t \"%r\" %>s %b \"%{X-Request-ID}o\"" common
+CustomLog /proc/self/fd/1 common
+
+RequestHeader setifempty X-Request-ID "%{UNIQUE_ID}e"
+Header always set X-Request-ID "expr=%{req:X-Request-ID}"
+Header always set X-Request-ID "%{UNIQUE_ID}e" "expr=-z %{req:X-Request-ID}"
+
+<Directory />
+  AllowOverride None
+  Require all denied
+</Directory>
+
+<Directory "${APP_ROOT}/public">
+  Require all granted
+
+  FallbackResource /index.php
+
+  <FilesMatch "\.php$">
+    SetHandler "proxy:fcgi://127.0.0.1:9000"
+  </FilesMatch>
+</Directory>
+
+<Files ".ht*">
+  Require all denied
+</Files>`), string(contents))
 			})
 
-			context("when the binding resolver fails", func() {
-				it.Before(func() {
-					bindingResolver.ResolveCall.Returns.Error = errors.New("failed to resolve binding")
-				})
-				it("returns an error", func() {
-					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
-					Expect(err).To(MatchError("failed to resolve binding"))
+			context("when the address is a unix socket", func() {
+				it("sets a unix socket handler", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerFastCGIAddress: "/tmp/php-fpm.socket"})
+					Expect(err).NotTo(HaveOccurred())
+
+					contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(contents)).To(ContainSubstring(`SetHandler "proxy:unix:/tmp/php-fpm.socket|fcgi://localhost"`))
 				})
 			})
 
-			context("when more than one binding is found", func() {
-				it.Before(func() {
-					bindingResolver.ResolveCall.Returns.BindingSlice = []servicebindings.Binding{
-						{
-							Name: "first",
-							Type: "htpasswd",
-							Path: "some-binding-path",
-							Entries: map[string]*servicebindings.Entry{
-								".htpasswd": servicebindings.NewEntry("some-path"),
-							},
-						},
-						{
-							Name: "second",
-							Type: "htpasswd",
-							Path: "some-binding-path",
-							Entries: map[string]*servicebindings.Entry{
-								".htpasswd": servicebindings.NewEntry("some-path"),
-							},
-						},
-					}
-				})
-				it("returns an error", func() {
-					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
-					Expect(err).To(MatchError("failed: binding resolver found more than one binding of type 'htpasswd'"))
+			context("when the address is a host and port", func() {
+				it("sets a TCP handler", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerFastCGIAddress: "php:9000"})
+					Expect(err).NotTo(HaveOccurred())
+
+					contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(contents)).To(ContainSubstring(`SetHandler "proxy:fcgi://php:9000"`))
 				})
 			})
+		})
 
-			context("when the binding is missing the required entry", func() {
-				it.Before(func() {
-					bindingResolver.ResolveCall.Returns.BindingSlice = []servicebindings.Binding{
-						{
-							Name: "first",
-							Type: "htpasswd",
-							Path: "some-binding-path",
-							Entries: map[string]*servicebindings.Entry{
-								"wrong-entry": servicebindings.NewEntry("some-path"),
-							},
-						},
+		context("when BP_WEB_SERVER_ENABLE_COMPRESSION is set", func() {
+			it("creates a config that compresses the default types", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerCompressionEnabled: true})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration that compresses responses of type text/html, text/plain"))
+
+				contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(Equal(`ServerRoot "${SERVER_ROOT}"
+
+ServerName "0.0.0.0"
+
+LoadModule mpm_event_module modules/mod_mpm_event.so
+LoadModule log_config_module modules/mod_log_config.so
+LoadModule mime_module modules/mod_mime.so
+LoadModule dir_module modules/mod_dir.so
+LoadModule authz_core_module modules/mod_authz_core.so
+LoadModule unixd_module modules/mod_unixd.so
+LoadModule headers_module modules/mod_headers.so
+LoadModule unique_id_module modules/mod_unique_id.so
+LoadModule filter_module modules/mod_filter.so
+LoadModule deflate_module modules/mod_deflate.so
+<IfFile modules/mod_brotli.so>
+  LoadModule brotli_module modules/mod_brotli.so
+</IfFile>
+
+TypesConfig conf/mime.types
+
+PidFile /tmp/httpd.pid
+
+User nobody
+
+ServerLimit ${HTTPD_SERVER_LIMIT}
+ThreadsPerChild ${HTTPD_THREADS_PER_CHILD}
+MaxRequestWorkers ${HTTPD_MAX_REQUEST_WORKERS}
+MaxConnectionsPerChild ${HTTPD_MAX_CONNECTIONS_PER_CHILD}
+
+Listen "${PORT}"
+
+DocumentRoot "${APP_ROOT}/public"
+
+DirectoryIndex index.html
+
+ErrorLog /proc/self/fd/2
+ErrorLogFormat "[%{u}t] [%-m:%l] [pid %P:tid %T] [request_id\ %{X-Request-ID}i] [unique_id\ %{UNIQUE_ID}e] %7F: %E: [client\ %a] %M% ,\ referer\ %{Referer}i"
+
+LogFormat "%h %l %u %t \"%r\" %>s %b \"%{X-Request-ID}o\"" common
+CustomLog /proc/self/fd/1 common
+
+RequestHeader setifempty X-Request-ID "%{UNIQUE_ID}e"
+Header always set X-Request-ID "expr=%{req:X-Request-ID}"
+Header always set X-Request-ID "%{UNIQUE_ID}e" "expr=-z %{req:X-Request-ID}"
+
+<IfModule brotli_module>
+  AddOutputFilterByType BROTLI_COMPRESS;DEFLATE text/html text/plain text/css text/xml text/javascript application/javascript application/json application/xml application/manifest+json image/svg+xml font/ttf font/otf
+</IfModule>
+<IfModule !brotli_module>
+  AddOutputFilterByType DEFLATE text/html text/plain text/css text/xml text/javascript application/javascript application/json application/xml application/manifest+json image/svg+xml font/ttf font/otf
+</IfModule>
+
+<Directory />
+  AllowOverride None
+  Require all denied
+</Directory>
+
+<Directory "${APP_ROOT}/public">
+  Require all granted
+</Directory>
+
+<Files ".ht*">
+  Require all denied
+</Files>`), string(contents))
+			})
+
+			context("when BP_WEB_SERVER_COMPRESSION_TYPES is set", func() {
+				it("compresses only those types", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+						WebServerCompressionEnabled: true,
+						WebServerCompressionTypes:   "text/html, application/wasm",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(contents)).To(ContainSubstring("\n  AddOutputFilterByType BROTLI_COMPRESS;DEFLATE text/html application/wasm\n"))
+					Expect(string(contents)).To(ContainSubstring("\n  AddOutputFilterByType DEFLATE text/html application/wasm\n"))
+				})
+			})
+		})
+
+		context("when BP_WEB_SERVER_ENABLE_PRECOMPRESSION is set", func() {
+			it("creates a config that serves precompressed files", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerPrecompressEnabled: true})
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(ContainSubstring(`LoadModule rewrite_module modules/mod_rewrite.so
+`))
+				Expect(string(contents)).To(ContainSubstring(`<Directory "${APP_ROOT}/public">
+  Require all granted
+
+  RewriteEngine On
+  RewriteCond "%{HTTP:Accept-Encoding}" "br"
+  RewriteCond "%{REQUEST_FILENAME}.br" -s
+  RewriteRule "^(.*)\.(css|html|js|json|map|mjs|svg|txt|wasm|xml)$" "$1.$2.br" [QSA]
+  RewriteCond "%{HTTP:Accept-Encoding}" "gzip"
+  RewriteCond "%{REQUEST_FILENAME}.gz" -s
+  RewriteRule "^(.*)\.(css|html|js|json|map|mjs|svg|txt|wasm|xml)$" "$1.$2.gz" [QSA]
+  RewriteRule "\.css\.(br|gz)$" "-" [T=text/css,E=no-brotli:1,E=no-gzip:1]
+`))
+				Expect(string(contents)).To(ContainSubstring(`  <FilesMatch "\.(css|html|js|json|map|mjs|svg|txt|wasm|xml)(\.br|\.gz)?$">
+    Header merge Vary Accept-Encoding
+  </FilesMatch>
+
+  <FilesMatch "\.(css|html|js|json|map|mjs|svg|txt|wasm|xml)\.br$">
+    Header set Content-Encoding br
+  </FilesMatch>
+
+  <FilesMatch "\.(css|html|js|json|map|mjs|svg|txt|wasm|xml)\.gz$">
+    Header set Content-Encoding gzip
+  </FilesMatch>
+</Directory>`))
+			})
+		})
+
+		context("when BP_WEB_SERVER_CACHE_POLICY is set", func() {
+			it("creates a config with cache headers for the matching requests", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+					WebServerCachePolicy: "/assets/*,*.????????.js=public, max-age=31536000, immutable;index.html=no-cache",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration that sets Cache-Control 'no-cache' for FilesMatch '^(index\\.html)$'"))
+
+				contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(ContainSubstring(`LoadModule unixd_module modules/mod_unixd.so
+LoadModule headers_module modules/mod_headers.so
+LoadModule unique_id_module modules/mod_unique_id.so
+LoadModule expires_module modules/mod_expires.so
+`))
+				Expect(string(contents)).To(HaveSuffix(`</Directory>
+
+<FilesMatch "^([^/]*\.[^/][^/][^/][^/][^/][^/][^/][^/]\.js)$">
+  ExpiresActive On
+  ExpiresDefault "access plus 31536000 seconds"
+  Header set Cache-Control "public, max-age=31536000, immutable"
+</FilesMatch>
+
+<LocationMatch "^(/assets/.*)$">
+  ExpiresActive On
+  ExpiresDefault "access plus 31536000 seconds"
+  Header set Cache-Control "public, max-age=31536000, immutable"
+</LocationMatch>
+
+<FilesMatch "^(index\.html)$">
+  Header set Cache-Control "no-cache"
+</FilesMatch>
+
+<Files ".ht*">
+  Require all denied
+</Files>`))
+			})
+
+			context("when there is a web-server.toml in the workspace", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "web-server.toml"), []byte(`
+[[cache]]
+  match = "*.css"
+  cache-control = "public, max-age=600"
+`), 0600)).To(Succeed())
+				})
+
+				it("applies the rules from the file before the environment variable", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+						WebServerCachePolicy: "*.html=no-store",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(contents)).To(ContainSubstring(`</Directory>
+
+<FilesMatch "^([^/]*\.css)$">
+  ExpiresActive On
+  ExpiresDefault "access plus 600 seconds"
+  Header set Cache-Control "public, max-age=600"
+</FilesMatch>
+
+<FilesMatch "^([^/]*\.html)$">
+  Header set Cache-Control "no-store"
+</FilesMatch>
+`))
+				})
+			})
+		})
+
+		context("when BP_WEB_SERVER_SECURITY_HEADERS is set", func() {
+			it("creates a config with the basic security headers", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerSecurityHeaders: "basic"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration that sets the X-Frame-Options header"))
+
+				contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(ContainSubstring(``))
+				Expect(string(contents)).To(ContainSubstring(`CustomLog /proc/self/fd/1 common
+
+RequestHeader setifempty X-Request-ID "%{UNIQUE_ID}e"
+Header always set X-Request-ID "expr=%{req:X-Request-ID}"
+Header always set X-Request-ID "%{UNIQUE_ID}e" "expr=-z %{req:X-Request-ID}"
+
+Header always set X-Frame-Options "SAMEORIGIN"
+Header always set X-Content-Type-Options "nosniff"
+Header always set Referrer-Policy "strict-origin-when-cross-origin"
+
+<Directory />`))
+			})
+
+			context("when the preset is strict and https is forced", func() {
+				it("creates a config with the strict security headers including HSTS", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+						WebServerSecurityHeaders: "strict",
+						WebServerForceHTTPS:      true,
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(contents)).To(ContainSubstring(`CustomLog /proc/self/fd/1 common
+
+RequestHeader setifempty X-Request-ID "%{UNIQUE_ID}e"
+Header always set X-Request-ID "expr=%{req:X-Request-ID}"
+Header always set X-Request-ID "%{UNIQUE_ID}e" "expr=-z %{req:X-Request-ID}"
+
+Header always set Strict-Transport-Security "max-age=63072000; includeSubDomains"
+Header always set Content-Security-Policy "default-src 'self'; base-uri 'self'; form-action 'self'; frame-ancestors 'none'"
+Header always set X-Frame-Options "DENY"
+Header always set X-Content-Type-Options "nosniff"
+Header always set Referrer-Policy "no-referrer"
+
+<Directory />`))
+				})
+			})
+
+			context("when headers are overridden", func() {
+				it("uses the overrides and drops headers that are turned off", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+						WebServerSecurityHeaders:         "strict",
+						WebServerContentSecurityPolicy:   "default-src 'self' https://cdn.example.com",
+						WebServerFrameOptions:            "off",
+						WebServerStrictTransportSecurity: "max-age=600",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(buffer.String()).To(ContainSubstring("WARNING: BP_WEB_SERVER_STRICT_TRANSPORT_SECURITY is ignored because BP_WEB_SERVER_FORCE_HTTPS is not set"))
+
+					contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(contents)).To(ContainSubstring(`CustomLog /proc/self/fd/1 common
+
+RequestHeader setifempty X-Request-ID "%{UNIQUE_ID}e"
+Header always set X-Request-ID "expr=%{req:X-Request-ID}"
+Header always set X-Request-ID "%{UNIQUE_ID}e" "expr=-z %{req:X-Request-ID}"
+
+Header always set Content-Security-Policy "default-src 'self' https://cdn.example.com"
+Header always set X-Content-Type-Options "nosniff"
+Header always set Referrer-Policy "no-referrer"
+
+<Directory />`))
+				})
+			})
+		})
+
+		context("when the tls service binding is set", func() {
+			it.Before(func() {
+				bindingResolver.ResolveCall.Stub = func(typ, provider, platformDir string) ([]servicebindings.Binding, error) {
+					if typ != "tls" {
+						return nil, nil
+					}
+
+					return []servicebindings.Binding{
+						{
+							Name: "first",
+							Type: "tls",
+							Path: "some-binding-path",
+							Entries: map[string]*servicebindings.Entry{
+								"tls.crt": servicebindings.NewEntry("some-path"),
+								"tls.key": servicebindings.NewEntry("some-path"),
+								"ca.crt":  servicebindings.NewEntry("some-path"),
+							},
+						},
+					}, nil
+				}
+			})
+
+			it("creates a config that terminates TLS", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration that terminates TLS on port 8443 from service binding"))
+
+				contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(Equal(`ServerRoot "${SERVER_ROOT}"
+
+ServerName "0.0.0.0"
+
+LoadModule mpm_event_module modules/mod_mpm_event.so
+LoadModule log_config_module modules/mod_log_config.so
+LoadModule mime_module modules/mod_mime.so
+LoadModule dir_module modules/mod_dir.so
+LoadModule authz_core_module modules/mod_authz_core.so
+LoadModule unixd_module modules/mod_unixd.so
+LoadModule headers_module modules/mod_headers.so
+LoadModule unique_id_module modules/mod_unique_id.so
+LoadModule socache_shmcb_module modules/mod_socache_shmcb.so
+LoadModule ssl_module modules/mod_ssl.so
+
+TypesConfig conf/mime.types
+
+PidFile /tmp/httpd.pid
+
+User nobody
+
+ServerLimit ${HTTPD_SERVER_LIMIT}
+ThreadsPerChild ${HTTPD_THREADS_PER_CHILD}
+MaxRequestWorkers ${HTTPD_MAX_REQUEST_WORKERS}
+MaxConnectionsPerChild ${HTTPD_MAX_CONNECTIONS_PER_CHILD}
+
+Listen "${PORT}"
+Listen 8443 https
+
+DocumentRoot "${APP_ROOT}/public"
+
+DirectoryIndex index.html
+
+ErrorLog /proc/self/fd/2
+ErrorLogFormat "[%{u}t] [%-m:%l] [pid %P:tid %T] [request_id\ %{X-Request-ID}i] [unique_id\ %{UNIQUE_ID}e] %7F: %E: [client\ %a] %M% ,\ referer\ %{Referer}i"
+
+LogFormat "%h %l %u %t \"%r\" %>s %b \"%{X-Request-ID}o\"" common
+CustomLog /proc/self/fd/1 common
+
+RequestHeader setifempty X-Request-ID "%{UNIQUE_ID}e"
+Header always set X-Request-ID "expr=%{req:X-Request-ID}"
+Header always set X-Request-ID "%{UNIQUE_ID}e" "expr=-z %{req:X-Request-ID}"
+
+SSLSessionCache "shmcb:/tmp/ssl_scache(512000)"
+SSLProtocol all -SSLv3 -TLSv1 -TLSv1.1
+
+<VirtualHost *:8443>
+  SSLEngine on
+  SSLCertificateFile "some-binding-path/tls.crt"
+  SSLCertificateKeyFile "some-binding-path/tls.key"
+  SSLCertificateChainFile "some-binding-path/ca.crt"
+</VirtualHost>
+
+<Directory />
+  AllowOverride None
+  Require all denied
+</Directory>
+
+<Directory "${APP_ROOT}/public">
+  Require all granted
+</Directory>
+
+<Files ".ht*">
+  Require all denied
+</Files>`), string(contents))
+			})
+
+			context("when BP_WEB_SERVER_TLS_PORT is set", func() {
+				it("listens on that port", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerTLSPort: "9443"})
+					Expect(err).NotTo(HaveOccurred())
+
+					contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(contents)).To(ContainSubstring("Listen 9443 https\n"))
+					Expect(string(contents)).To(ContainSubstring("<VirtualHost *:9443>\n"))
+				})
+			})
+		})
+
+		context("when htpasswd service bindings declare paths and realms", func() {
+			it.Before(func() {
+				for name, content := range map[string]string{
+					"admin-path":    "/admin/\n",
+					"admin-realm":   "Administrators\n",
+					"reports-path":  "/reports",
+					"reports-realm": "Reports",
+				} {
+					Expect(os.WriteFile(filepath.Join(workingDir, name), []byte(content), 0600)).To(Succeed())
+				}
+
+				bindingResolver.ResolveCall.Stub = func(typ, provider, platformDir string) ([]servicebindings.Binding, error) {
+					if typ != "htpasswd" {
+						return nil, nil
+					}
+
+					return []servicebindings.Binding{
+						{
+							Name: "reports",
+							Type: "htpasswd",
+							Path: "reports-binding-path",
+							Entries: map[string]*servicebindings.Entry{
+								".htpasswd": servicebindings.NewEntry("some-path"),
+								"path":      servicebindings.NewEntry(filepath.Join(workingDir, "reports-path")),
+								"realm":     servicebindings.NewEntry(filepath.Join(workingDir, "reports-realm")),
+							},
+						},
+						{
+							Name: "admin",
+							Type: "htpasswd",
+							Path: "admin-binding-path",
+							Entries: map[string]*servicebindings.Entry{
+								".htpasswd": servicebindings.NewEntry("some-path"),
+								"path":      servicebindings.NewEntry(filepath.Join(workingDir, "admin-path")),
+								"realm":     servicebindings.NewEntry(filepath.Join(workingDir, "admin-realm")),
+							},
+						},
+					}, nil
+				}
+			})
+
+			it("creates a config that protects each path with its own user list", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration that configured basic authentication for '/admin' from service binding"))
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration that configured basic authentication for '/reports' from service binding"))
+
+				contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(Equal(`ServerRoot "${SERVER_ROOT}"
+
+ServerName "0.0.0.0"
+
+LoadModule mpm_event_module modules/mod_mpm_event.so
+LoadModule log_config_module modules/mod_log_config.so
+LoadModule mime_module modules/mod_mime.so
+LoadModule dir_module modules/mod_dir.so
+LoadModule authz_core_module modules/mod_authz_core.so
+LoadModule unixd_module modules/mod_unixd.so
+LoadModule headers_module modules/mod_headers.so
+LoadModule unique_id_module modules/mod_unique_id.so
+LoadModule authn_core_module modules/mod_authn_core.so
+LoadModule authn_file_module modules/mod_authn_file.so
+LoadModule authz_host_module modules/mod_authz_host.so
+LoadModule authz_user_module modules/mod_authz_user.so
+LoadModule access_compat_module modules/mod_access_compat.so
+LoadModule auth_basic_module modules/mod_auth_basic.so
+
+TypesConfig conf/mime.types
+
+PidFile /tmp/httpd.pid
+
+User nobody
+
+ServerLimit ${HTTPD_SERVER_LIMIT}
+ThreadsPerChild ${HTTPD_THREADS_PER_CHILD}
+MaxRequestWorkers ${HTTPD_MAX_REQUEST_WORKERS}
+MaxConnectionsPerChild ${HTTPD_MAX_CONNECTIONS_PER_CHILD}
+
+Listen "${PORT}"
+
+DocumentRoot "${APP_ROOT}/public"
+
+DirectoryIndex index.html
+
+ErrorLog /proc/self/fd/2
+ErrorLogFormat "[%{u}t] [%-m:%l] [pid %P:tid %T] [request_id\ %{X-Request-ID}i] [unique_id\ %{UNIQUE_ID}e] %7F: %E: [client\ %a] %M% ,\ referer\ %{Referer}i"
+
+LogFormat "%h %l %u %t \"%r\" %>s %b \"%{X-Request-ID}o\"" common
+CustomLog /proc/self/fd/1 common
+
+RequestHeader setifempty X-Request-ID "%{UNIQUE_ID}e"
+Header always set X-Request-ID "expr=%{req:X-Request-ID}"
+Header always set X-Request-ID "%{UNIQUE_ID}e" "expr=-z %{req:X-Request-ID}"
+
+<Directory />
+  AllowOverride None
+  Require all denied
+</Directory>
+
+<Directory "${APP_ROOT}/public">
+  Require all granted
+</Directory>
+
+<Location "/admin">
+  AuthType Basic
+  AuthName "Administrators"
+  AuthUserFile "admin-binding-path/.htpasswd"
+  Require valid-user
+</Location>
+
+<Location "/reports">
+  AuthType Basic
+  AuthName "Reports"
+  AuthUserFile "reports-binding-path/.htpasswd"
+  Require valid-user
+</Location>
+
+<Files ".ht*">
+  Require all denied
+</Files>`), string(contents))
+			})
+		})
+
+		context("when the web server root contains error pages", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(workingDir, "public", "errors"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "public", "404.html"), nil, 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "public", "403.html"), nil, 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "public", "50x.html"), nil, 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "public", "index.html"), nil, 0600)).To(Succeed())
+			})
+
+			it("serves them for their status codes", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration that serves '/404.html' for status 404"))
+
+				contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(ContainSubstring(`CustomLog /proc/self/fd/1 common
+
+RequestHeader setifempty X-Request-ID "%{UNIQUE_ID}e"
+Header always set X-Request-ID "expr=%{req:X-Request-ID}"
+Header always set X-Request-ID "%{UNIQUE_ID}e" "expr=-z %{req:X-Request-ID}"
+
+ErrorDocument 403 /403.html
+ErrorDocument 404 /404.html
+ErrorDocument 500 /50x.html
+ErrorDocument 502 /50x.html
+ErrorDocument 503 /50x.html
+ErrorDocument 504 /50x.html
+
+<Directory />`))
+			})
+
+			context("when BP_WEB_SERVER_ERROR_PAGES is set", func() {
+				it("overrides the conventionally named pages", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+						WebServerErrorPages: "503=/errors/maintenance.html, 410=/errors/gone.html",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(contents)).To(ContainSubstring(`
+ErrorDocument 403 /403.html
+ErrorDocument 404 /404.html
+ErrorDocument 410 /errors/gone.html
+ErrorDocument 500 /50x.html
+ErrorDocument 502 /50x.html
+ErrorDocument 503 /errors/maintenance.html
+ErrorDocument 504 /50x.html
+`))
+				})
+			})
+
+			context("when push state is enabled", func() {
+				it("lets the push state fallback handle unknown paths instead of 404.html", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+						WebServerPushStateEnabled: true,
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(contents)).To(ContainSubstring("ErrorDocument 403 /403.html\n"))
+					Expect(string(contents)).NotTo(ContainSubstring("ErrorDocument 404"))
+				})
+
+				context("when a custom 404 page is configured", func() {
+					it("serves it", func() {
+						err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+							WebServerPushStateEnabled: true,
+							WebServerErrorPages:       "404=/404.html",
+						})
+						Expect(err).NotTo(HaveOccurred())
+
+						contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(string(contents)).To(ContainSubstring("ErrorDocument 404 /404.html\n"))
+					})
+				})
+			})
+		})
+
+		context("when the web server root contains _redirects and _headers files", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(workingDir, "public"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "public", "_redirects"), []byte(`# some comment
+/home              /
+/blog/:year/:slug  /posts/:year-:slug  302
+/docs/*            /documentation/:splat  301!
+/api/*             https://api.example.com/:splat  200
+/app/*             /app/index.html  200
+/*                 /404.html  404
+https://old.example.com/* https://example.com/:splat 301!
+/store id=:id      /products/:id  301
+/en/*              /en/:splat  302  Country=us
+/missing           /:id
+/gone              /500.html  500
+`), 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "public", "_headers"), []byte(`/*
+  X-Frame-Options: DENY
+  X-Robots-Tag: noindex
+
+/assets/:hash/*.js
+  Cache-Control: public, max-age=31536000
+  Invalid Header
+https://example.com/*
+  X-Some-Header: some-value
+`), 0600)).To(Succeed())
+			})
+
+			it("translates them into rewrite rules and headers", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration for 6 rules from _redirects"))
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration for 2 paths from _headers"))
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration that serves '/404.html' for status 404 on '^/.*$'"))
+				Expect(buffer.String()).To(ContainSubstring("WARNING: _redirects:8: domain-level redirects are not supported"))
+				Expect(buffer.String()).To(ContainSubstring("WARNING: _redirects:9: query parameter matching is not supported"))
+				Expect(buffer.String()).To(ContainSubstring(`WARNING: _redirects:10: conditions "Country=us" are not supported`))
+				Expect(buffer.String()).To(ContainSubstring("WARNING: _redirects:11: placeholder :id is not defined in the source path"))
+				Expect(buffer.String()).To(ContainSubstring("WARNING: _redirects:12: status 500 is not supported"))
+				Expect(buffer.String()).To(ContainSubstring(`WARNING: _headers:7: header "Invalid Header" is not a valid 'Name: value' pair`))
+				Expect(buffer.String()).To(ContainSubstring("WARNING: _headers:8: domain-level header rules are not supported"))
+				Expect(buffer.String()).To(ContainSubstring(`WARNING: _headers:9: header "X-Some-Header: some-value" does not follow a path`))
+
+				contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(Equal(`ServerRoot "${SERVER_ROOT}"
+
+ServerName "0.0.0.0"
+
+LoadModule mpm_event_module modules/mod_mpm_event.so
+LoadModule log_config_module modules/mod_log_config.so
+LoadModule mime_module modules/mod_mime.so
+LoadModule dir_module modules/mod_dir.so
+LoadModule authz_core_module modules/mod_authz_core.so
+LoadModule unixd_module modules/mod_unixd.so
+LoadModule headers_module modules/mod_headers.so
+LoadModule unique_id_module modules/mod_unique_id.so
+LoadModule rewrite_module modules/mod_rewrite.so
+LoadModule proxy_module modules/mod_proxy.so
+LoadModule proxy_http_module modules/mod_proxy_http.so
+
+TypesConfig conf/mime.types
+
+PidFile /tmp/httpd.pid
+
+User nobody
+
+ServerLimit ${HTTPD_SERVER_LIMIT}
+ThreadsPerChild ${HTTPD_THREADS_PER_CHILD}
+MaxRequestWorkers ${HTTPD_MAX_REQUEST_WORKERS}
+MaxConnectionsPerChild ${HTTPD_MAX_CONNECTIONS_PER_CHILD}
+
+Listen "${PORT}"
+
+DocumentRoot "${APP_ROOT}/public"
+
+DirectoryIndex index.html
+
+ErrorLog /proc/self/fd/2
+ErrorLogFormat "[%{u}t] [%-m:%l] [pid %P:tid %T] [request_id\ %{X-Request-ID}i] [unique_id\ %{UNIQUE_ID}e] %7F: %E: [client\ %a] %M% ,\ referer\ %{Referer}i"
+
+LogFormat "%h %l %u %t \"%r\" %>s %b \"%{X-Request-ID}o\"" common
+CustomLog /proc/self/fd/1 common
+
+RequestHeader setifempty X-Request-ID "%{UNIQUE_ID}e"
+Header always set X-Request-ID "expr=%{req:X-Request-ID}"
+Header always set X-Request-ID "%{UNIQUE_ID}e" "expr=-z %{req:X-Request-ID}"
+
+<Directory />
+  AllowOverride None
+  Require all denied
+</Directory>
+
+<Directory "${APP_ROOT}/public">
+  Require all granted
+
+  RewriteEngine On
+  RewriteCond %{REQUEST_FILENAME} !-f
+  RewriteCond %{REQUEST_FILENAME} !-d
+  RewriteRule "^home/?$" "/" [R=301,L]
+  RewriteCond %{REQUEST_FILENAME} !-f
+  RewriteCond %{REQUEST_FILENAME} !-d
+  RewriteRule "^blog/([^/]+)/([^/]+)/?$" "/posts/$1-$2" [R=302,L]
+  RewriteRule "^docs/(.*)$" "/documentation/$1" [R=301,L]
+  RewriteCond %{REQUEST_FILENAME} !-f
+  RewriteCond %{REQUEST_FILENAME} !-d
+  RewriteRule "^api/(.*)$" "https://api.example.com/$1" [P,L]
+  RewriteCond %{REQUEST_FILENAME} !-f
+  RewriteCond %{REQUEST_FILENAME} !-d
+  RewriteRule "^app/(.*)$" "/app/index.html" [END]
+  RewriteCond %{REQUEST_FILENAME} !-f
+  RewriteCond %{REQUEST_FILENAME} !-d
+  RewriteRule "^(.*)$" "-" [R=404,L]
+</Directory>
+
+<LocationMatch "^/.*$">
+  ErrorDocument 404 /404.html
+</LocationMatch>
+
+<LocationMatch "^/.*$">
+  Header set X-Frame-Options "DENY"
+  Header set X-Robots-Tag "noindex"
+</LocationMatch>
+
+<LocationMatch "^/assets/[^/]+/.*\.js$">
+  Header set Cache-Control "public, max-age=31536000"
+</LocationMatch>
+
+<Files ".ht*">
+  Require all denied
+</Files>
+
+<FilesMatch "^_(redirects|headers)$">
+  Require all denied
+</FilesMatch>`), string(contents))
+			})
+
+			context("when BP_WEB_SERVER_FORCE_HTTPS is set", func() {
+				it("redirects to https before the _redirects rules serve or proxy a request", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerForceHTTPS: true})
+					Expect(err).NotTo(HaveOccurred())
+
+					contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(contents)).To(ContainSubstring(`<Directory "${APP_ROOT}/public">
+  Require all granted
+
+  RewriteEngine On
+  RewriteCond %{HTTPS} !=on
+  RewriteCond %{HTTP:X-Forwarded-Proto} !https [NC]
+  RewriteRule ^ https://%{HTTP_HOST}%{REQUEST_URI} [L,R=301]
+
+  RewriteEngine On
+  RewriteCond %{REQUEST_FILENAME} !-f
+  RewriteCond %{REQUEST_FILENAME} !-d
+  RewriteRule "^home/?$" "/" [R=301,L]
+`))
+					Expect(string(contents)).To(ContainSubstring(`  RewriteRule "^api/(.*)$" "https://api.example.com/$1" [P,L]
+`))
+					Expect(string(contents)).To(ContainSubstring(`  RewriteRule "^app/(.*)$" "/app/index.html" [END]
+`))
+				})
+			})
+		})
+
+		context("when the _redirects file serves different pages for a 4xx status on different paths", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(workingDir, "public"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "public", "_redirects"), []byte(`/shop/*   /closed.html  404
+/gone     /gone.html    410
+/*        /404.html     404
+`), 0600)).To(Succeed())
+			})
+
+			it("scopes each page to the paths of its rule, the first matching rule taking precedence", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration for 3 rules from _redirects"))
+				Expect(buffer.String()).NotTo(ContainSubstring("WARNING"))
+
+				contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).NotTo(MatchRegexp("(?m)^ErrorDocument"))
+				Expect(string(contents)).To(ContainSubstring(`</Directory>
+
+<LocationMatch "^/.*$">
+  ErrorDocument 404 /404.html
+</LocationMatch>
+
+<LocationMatch "^/gone/?$">
+  ErrorDocument 410 /gone.html
+</LocationMatch>
+
+<LocationMatch "^/shop/.*$">
+  ErrorDocument 404 /closed.html
+</LocationMatch>
+`))
+			})
+		})
+
+		context("when the web server root contains only a _headers file", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(workingDir, "public"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "public", "_headers"), []byte("/*\n  X-Frame-Options: DENY\n"), 0600)).To(Succeed())
+			})
+
+			it("denies access to the _redirects and _headers files", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(HaveSuffix(`
+<FilesMatch "^_(redirects|headers)$">
+  Require all denied
+</FilesMatch>`))
+			})
+		})
+
+		context("when the app provides an httpd.conf.tmpl", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "httpd.conf.tmpl"), []byte(`# company template
+{{template "httpd.conf" .}}
+
+DocumentRoot "{{.WebServerRoot}}"
+{{- if .BasicAuthFile}}
+# protected by {{.BasicAuthFile}}
+{{- end}}
+Header set X-Compression-Types "{{join (split .WebServerCompressionTypes) ","}}"
+`), 0600)).To(Succeed())
+			})
+
+			context("when the template is valid", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "httpd.conf.tmpl"), []byte(`# company template
+{{template "httpd.conf" .}}
+{{- if hasPrefix .WebServerRoot "${APP_ROOT}"}}
+# serving {{.WebServerRoot}}
+{{- end}}
+{{- if .BasicAuthFile}}
+# protected by {{.BasicAuthFile}}
+{{- end}}
+`), 0600)).To(Succeed())
+
+					bindingResolver.ResolveCall.Stub = func(typ, provider, platformDir string) ([]servicebindings.Binding, error) {
+						if typ != "htpasswd" {
+							return nil, nil
+						}
+
+						return []servicebindings.Binding{
+							{
+								Name: "first",
+								Type: "htpasswd",
+								Path: "some-binding-path",
+								Entries: map[string]*servicebindings.Entry{
+									".htpasswd": servicebindings.NewEntry("some-path"),
+								},
+							},
+						}, nil
+					}
+				})
+
+				it("renders it with the build environment", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerRoot: "htdocs"})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(buffer.String()).To(ContainSubstring("Renders configuration from 'httpd.conf.tmpl'"))
+
+					contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(contents)).To(HavePrefix("# company template\nServerRoot \"${SERVER_ROOT}\"\n"))
+					Expect(string(contents)).To(ContainSubstring(`<Directory "${APP_ROOT}/htdocs">`))
+					Expect(string(contents)).To(HaveSuffix(`</Files>
+# serving ${APP_ROOT}/htdocs
+# protected by some-binding-path/.htpasswd
+`))
+				})
+			})
+
+			context("when the template cannot be parsed", func() {
+				it("returns an error with the line of the template", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+					Expect(err).To(MatchError(`failed to parse httpd.conf.tmpl: template: httpd.conf.tmpl:8: function "split" not defined`))
+
+					Expect(filepath.Join(workingDir, "httpd.conf")).NotTo(BeAnExistingFile())
+				})
+			})
+
+			context("when the template cannot be rendered", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "httpd.conf.tmpl"), []byte("Listen \"${PORT}\"\nDocumentRoot {{.DocumentRoot}}\n"), 0600)).To(Succeed())
+				})
+
+				it("returns an error with the line of the template", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+					Expect(err).To(MatchError(ContainSubstring(`failed to render httpd.conf.tmpl: template: httpd.conf.tmpl:2:15: executing "httpd.conf.tmpl" at <.DocumentRoot>: can't evaluate field DocumentRoot`)))
+
+					Expect(filepath.Join(workingDir, "httpd.conf")).NotTo(BeAnExistingFile())
+				})
+			})
+		})
+
+		context("when config snippets are provided", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(workingDir, "httpd.d", "nested"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "httpd.d", "20-headers.conf"), nil, 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "httpd.d", "10-timeout.conf"), nil, 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "httpd.d", "README.md"), nil, 0600)).To(Succeed())
+
+				bindingResolver.ResolveCall.Stub = func(typ, provider, platformDir string) ([]servicebindings.Binding, error) {
+					if typ != "httpd-config" {
+						return nil, nil
+					}
+
+					return []servicebindings.Binding{
+						{
+							Name: "second",
+							Type: "httpd-config",
+							Path: "second-binding-path",
+							Entries: map[string]*servicebindings.Entry{
+								"b.conf": servicebindings.NewEntry("some-path"),
+								"a.conf": servicebindings.NewEntry("some-path"),
+								"type":   servicebindings.NewEntry("some-path"),
+							},
+						},
+						{
+							Name: "first",
+							Type: "httpd-config",
+							Path: "first-binding-path",
+							Entries: map[string]*servicebindings.Entry{
+								"z.conf": servicebindings.NewEntry("some-path"),
+							},
+						},
+					}, nil
+				}
+			})
+
+			it("includes the app snippets followed by the binding snippets", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration that includes '${APP_ROOT}/httpd.d/10-timeout.conf'"))
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration that includes 'first-binding-path/z.conf'"))
+
+				contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(HaveSuffix(`<Files ".ht*">
+  Require all denied
+</Files>
+
+Include "${APP_ROOT}/httpd.d/10-timeout.conf"
+Include "${APP_ROOT}/httpd.d/20-headers.conf"
+Include "first-binding-path/z.conf"
+Include "second-binding-path/a.conf"
+Include "second-binding-path/b.conf"`))
+			})
+		})
+
+		context("when the htpasswd service binding is set", func() {
+			it.Before(func() {
+				bindingResolver.ResolveCall.Stub = func(typ, provider, platformDir string) ([]servicebindings.Binding, error) {
+					if typ != "htpasswd" {
+						return nil, nil
+					}
+
+					return []servicebindings.Binding{
+						{
+							Name: "first",
+							Type: "htpasswd",
+							Path: "some-binding-path",
+							Entries: map[string]*servicebindings.Entry{
+								".htpasswd": servicebindings.NewEntry("some-path"),
+							},
+						},
+					}, nil
+				}
+			})
+
+			it("creates a config with that requires basic auth", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(bindingResolver.ResolveCall.Receives.Typ).To(Equal("htpasswd"))
+				Expect(bindingResolver.ResolveCall.Receives.Provider).To(Equal(""))
+				Expect(bindingResolver.ResolveCall.Receives.PlatformDir).To(Equal("platform"))
+
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration that configured basic authentication from service binding"))
+
+				contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(Equal(`ServerRoot "${SERVER_ROOT}"
+
+ServerName "0.0.0.0"
+
+LoadModule mpm_event_module modules/mod_mpm_event.so
+LoadModule log_config_module modules/mod_log_config.so
+LoadModule mime_module modules/mod_mime.so
+LoadModule dir_module modules/mod_dir.so
+LoadModule authz_core_module modules/mod_authz_core.so
+LoadModule unixd_module modules/mod_unixd.so
+LoadModule headers_module modules/mod_headers.so
+LoadModule unique_id_module modules/mod_unique_id.so
+LoadModule authn_core_module modules/mod_authn_core.so
+LoadModule authn_file_module modules/mod_authn_file.so
+LoadModule authz_host_module modules/mod_authz_host.so
+LoadModule authz_user_module modules/mod_authz_user.so
+LoadModule access_compat_module modules/mod_access_compat.so
+LoadModule auth_basic_module modules/mod_auth_basic.so
+
+TypesConfig conf/mime.types
+
+PidFile /tmp/httpd.pid
+
+User nobody
+
+ServerLimit ${HTTPD_SERVER_LIMIT}
+ThreadsPerChild ${HTTPD_THREADS_PER_CHILD}
+MaxRequestWorkers ${HTTPD_MAX_REQUEST_WORKERS}
+MaxConnectionsPerChild ${HTTPD_MAX_CONNECTIONS_PER_CHILD}
+
+Listen "${PORT}"
+
+DocumentRoot "${APP_ROOT}/public"
+
+DirectoryIndex index.html
+
+ErrorLog /proc/self/fd/2
+ErrorLogFormat "[%{u}t] [%-m:%l] [pid %P:tid %T] [request_id\ %{X-Request-ID}i] [unique_id\ %{UNIQUE_ID}e] %7F: %E: [client\ %a] %M% ,\ referer\ %{Referer}i"
+
+LogFormat "%h %l %u %t \"%r\" %>s %b \"%{X-Request-ID}o\"" common
+CustomLog /proc/self/fd/1 common
+
+RequestHeader setifempty X-Request-ID "%{UNIQUE_ID}e"
+Header always set X-Request-ID "expr=%{req:X-Request-ID}"
+Header always set X-Request-ID "%{UNIQUE_ID}e" "expr=-z %{req:X-Request-ID}"
+
+<Directory />
+  AllowOverride None
+  Require all denied
+</Directory>
+
+<Directory "${APP_ROOT}/public">
+  Require valid-user
+
+  AuthType Basic
+  AuthName "Authentication Required"
+  AuthUserFile "some-binding-path/.htpasswd"
+
+  Order allow,deny
+  Allow from all
+</Directory>
+
+<Files ".ht*">
+  Require all denied
+</Files>`), string(contents))
+			})
+
+			context("when the bindings were resolved at build time", func() {
+				it("uses the recorded files without resolving the bindings again", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+						BasicAuthFile:    "recorded-binding-path/.htpasswd",
+						BasicAuthRealm:   "Authentication Required",
+						BindingsResolved: true,
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(bindingResolver.ResolveCall.CallCount).To(Equal(0))
+					Expect(buffer.String()).To(ContainSubstring("Adds configuration that configured basic authentication from service binding"))
+
+					contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(contents)).To(ContainSubstring(`AuthUserFile "recorded-binding-path/.htpasswd"`))
+				})
+			})
+
+			context("when the health check and server status endpoints are set", func() {
+				it("excludes them from basic auth and the https redirect", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+						WebServerForceHTTPS:      true,
+						HealthCheckFilePath:      "/layers/launch-config/health.txt",
+						WebServerHealthCheckPath: "/healthz",
+						WebServerStatusPath:      "/server-status/",
+						WebServerStatusAllow:     "10.0.0.0/8, 127.0.0.1",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(buffer.String()).To(ContainSubstring("Adds configuration that answers health checks at '/healthz'"))
+					Expect(buffer.String()).To(ContainSubstring("Adds configuration that serves the server status at '/server-status' to 10.0.0.0/8, 127.0.0.1"))
+
+					contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(contents)).To(Equal(`ServerRoot "${SERVER_ROOT}"
+
+ServerName "0.0.0.0"
+
+LoadModule mpm_event_module modules/mod_mpm_event.so
+LoadModule log_config_module modules/mod_log_config.so
+LoadModule mime_module modules/mod_mime.so
+LoadModule dir_module modules/mod_dir.so
+LoadModule authz_core_module modules/mod_authz_core.so
+LoadModule unixd_module modules/mod_unixd.so
+LoadModule headers_module modules/mod_headers.so
+LoadModule unique_id_module modules/mod_unique_id.so
+LoadModule rewrite_module modules/mod_rewrite.so
+LoadModule alias_module modules/mod_alias.so
+LoadModule status_module modules/mod_status.so
+LoadModule authn_core_module modules/mod_authn_core.so
+LoadModule authn_file_module modules/mod_authn_file.so
+LoadModule authz_host_module modules/mod_authz_host.so
+LoadModule authz_user_module modules/mod_authz_user.so
+LoadModule access_compat_module modules/mod_access_compat.so
+LoadModule auth_basic_module modules/mod_auth_basic.so
+
+TypesConfig conf/mime.types
+
+PidFile /tmp/httpd.pid
+
+User nobody
+
+ServerLimit ${HTTPD_SERVER_LIMIT}
+ThreadsPerChild ${HTTPD_THREADS_PER_CHILD}
+MaxRequestWorkers ${HTTPD_MAX_REQUEST_WORKERS}
+MaxConnectionsPerChild ${HTTPD_MAX_CONNECTIONS_PER_CHILD}
+
+Listen "${PORT}"
+
+DocumentRoot "${APP_ROOT}/public"
+
+DirectoryIndex index.html
+
+ErrorLog /proc/self/fd/2
+ErrorLogFormat "[%{u}t] [%-m:%l] [pid %P:tid %T] [request_id\ %{X-Request-ID}i] [unique_id\ %{UNIQUE_ID}e] %7F: %E: [client\ %a] %M% ,\ referer\ %{Referer}i"
+
+LogFormat "%h %l %u %t \"%r\" %>s %b \"%{X-Request-ID}o\"" common
+CustomLog /proc/self/fd/1 common
+
+RequestHeader setifempty X-Request-ID "%{UNIQUE_ID}e"
+Header always set X-Request-ID "expr=%{req:X-Request-ID}"
+Header always set X-Request-ID "%{UNIQUE_ID}e" "expr=-z %{req:X-Request-ID}"
+
+<Directory />
+  AllowOverride None
+  Require all denied
+</Directory>
+
+<Directory "${APP_ROOT}/public">
+  Require valid-user
+
+  RewriteEngine On
+  RewriteRule "^server-status$" "-" [END]
+
+  RewriteEngine On
+  RewriteCond %{HTTPS} !=on
+  RewriteCond %{HTTP:X-Forwarded-Proto} !https [NC]
+  RewriteRule ^ https://%{HTTP_HOST}%{REQUEST_URI} [L,R=301]
+
+  AuthType Basic
+  AuthName "Authentication Required"
+  AuthUserFile "some-binding-path/.htpasswd"
+
+  Order allow,deny
+  Allow from all
+</Directory>
+
+Alias "/healthz" "/layers/launch-config/health.txt"
+
+<Location "/healthz">
+  Require all granted
+</Location>
+
+<Location "/server-status">
+  SetHandler server-status
+  Require ip 10.0.0.0/8 127.0.0.1
+</Location>
+
+<Files ".ht*">
+  Require all denied
+</Files>`), string(contents))
+				})
+			})
+		})
+
+		context("when BP_WEB_SERVER_STATUS_PATH is set", func() {
+			it("limits the server status to localhost", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+					WebServerStatusPath:     "/-/status",
+					WebServerFastCGIAddress: "localhost:9000",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration that serves the server status at '/-/status' to 127.0.0.1, ::1"))
+
+				contents, err := os.ReadFile(filepath.Join(workingDir, "httpd.conf"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(ContainSubstring("LoadModule authz_host_module modules/mod_authz_host.so\n"))
+				Expect(string(contents)).To(ContainSubstring(`
+  RewriteEngine On
+  RewriteRule "^-/status$" "-" [END]
+`))
+				Expect(string(contents)).To(ContainSubstring(`
+<Location "/-/status">
+  SetHandler server-status
+  Require ip 127.0.0.1 ::1
+  FallbackResource disabled
+</Location>
+`))
+			})
+		})
+
+		context("when BP_WEB_SERVER_STATUS_ALLOW is set without BP_WEB_SERVER_STATUS_PATH", func() {
+			it("warns that it is ignored", func() {
+				err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerStatusAllow: "10.0.0.0/8"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("WARNING: BP_WEB_SERVER_STATUS_ALLOW is ignored because BP_WEB_SERVER_STATUS_PATH is not set"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the health check path is not below '/'", func() {
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerHealthCheckPath: "/"})
+					Expect(err).To(MatchError(`failed: BP_WEB_SERVER_HEALTH_CHECK_PATH "/" must be a path below '/' without whitespace, quotes, backslashes or '${'`))
+				})
+			})
+
+			context("when the status path contains a quote", func() {
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerStatusPath: `/status"`})
+					Expect(err).To(MatchError(ContainSubstring(`BP_WEB_SERVER_STATUS_PATH "/status\""`)))
+				})
+			})
+
+			context("when the health check and status paths are the same", func() {
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+						WebServerHealthCheckPath: "/healthz",
+						WebServerStatusPath:      "/healthz",
+					})
+					Expect(err).To(MatchError("failed: BP_WEB_SERVER_HEALTH_CHECK_PATH and BP_WEB_SERVER_STATUS_PATH cannot both be '/healthz'"))
+				})
+			})
+
+			context("when the status allow list contains an invalid entry", func() {
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+						WebServerStatusPath:  "/server-status",
+						WebServerStatusAllow: "10.0.0.0/33",
+					})
+					Expect(err).To(MatchError(`failed: BP_WEB_SERVER_STATUS_ALLOW entry "10.0.0.0/33" is not an IP address or CIDR range`))
+				})
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the config file cannot be created", func() {
+				it.Before(func() {
+					Expect(os.Chmod(workingDir, 0000)).To(Succeed())
+				})
+
+				it.After(func() {
+					Expect(os.Chmod(workingDir, os.ModePerm)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+					Expect(err).To(MatchError(ContainSubstring("permission denied")))
+				})
+			})
+
+			context("when both a proxy upstream and a FastCGI address are set", func() {
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+						WebServerProxyUpstream:  "127.0.0.1:8080",
+						WebServerFastCGIAddress: "9000",
+					})
+					Expect(err).To(MatchError("failed: BP_WEB_SERVER_PROXY_UPSTREAM and BP_WEB_SERVER_FASTCGI_ADDRESS cannot both be set"))
+				})
+			})
+
+			context("when the cache policy is malformed", func() {
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerCachePolicy: "*.js"})
+					Expect(err).To(MatchError(`failed to parse cache policy "*.js": expected <pattern>=<cache-control>`))
+				})
+			})
+
+			context("when the cache-control value contains a quote", func() {
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerCachePolicy: `*.js=no-cache"`})
+					Expect(err).To(MatchError(ContainSubstring("invalid cache-control")))
+				})
+			})
+
+			context("when the web-server.toml is malformed", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "web-server.toml"), []byte("%%%"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+					Expect(err).To(MatchError(ContainSubstring("failed to parse web-server.toml")))
+				})
+			})
+
+			context("when the security headers preset is unknown", func() {
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerSecurityHeaders: "paranoid"})
+					Expect(err).To(MatchError("failed: unknown BP_WEB_SERVER_SECURITY_HEADERS preset 'paranoid', must be one of 'off', 'basic' or 'strict'"))
+				})
+			})
+
+			context("when BP_WEB_SERVER_ROOT would add a directive", func() {
+				it("returns an error without writing the config", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerRoot: "public\"\nLoadModule cgi_module modules/mod_cgi.so\n#"})
+					Expect(err).To(MatchError(`failed: BP_WEB_SERVER_ROOT "public\"\nLoadModule cgi_module modules/mod_cgi.so\n#" must not contain quotes, backslashes, control characters or '${'`))
+
+					Expect(filepath.Join(workingDir, "httpd.conf")).NotTo(BeAnExistingFile())
+				})
+			})
+
+			context("when BP_WEB_SERVER_ROOT references a variable", func() {
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerRoot: "${HOME}"})
+					Expect(err).To(MatchError(`failed: BP_WEB_SERVER_ROOT "${HOME}" must not contain quotes, backslashes, control characters or '${'`))
+				})
+			})
+
+			context("when BP_WEB_SERVER_PROXY_UPSTREAM contains whitespace", func() {
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerProxyUpstream: "localhost:3000 [E=foo:bar]"})
+					Expect(err).To(MatchError(`failed: BP_WEB_SERVER_PROXY_UPSTREAM "localhost:3000 [E=foo:bar]" must not contain whitespace`))
+				})
+			})
+
+			context("when a cache policy pattern contains a quote", func() {
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerCachePolicy: `*.css">=no-cache`})
+					Expect(err).To(MatchError(`failed: cache policy pattern "*.css\">" must not contain quotes, backslashes, control characters or '${'`))
+				})
+			})
+
+			context("when an htpasswd binding path contains a quote", func() {
+				it.Before(func() {
+					bindingResolver.ResolveCall.Stub = func(typ, provider, platformDir string) ([]servicebindings.Binding, error) {
+						if typ != "htpasswd" {
+							return nil, nil
+						}
+
+						return []servicebindings.Binding{
+							{
+								Name: "first",
+								Type: "htpasswd",
+								Path: `some-binding-path"`,
+								Entries: map[string]*servicebindings.Entry{
+									".htpasswd": servicebindings.NewEntry("some-path"),
+								},
+							},
+						}, nil
+					}
+				})
+
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+					Expect(err).To(MatchError(`failed: path of binding 'first' "some-binding-path\"" must not contain quotes, backslashes, control characters or '${'`))
+				})
+			})
+
+			context("when a security header override contains a quote", func() {
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerReferrerPolicy: `no-referrer"`})
+					Expect(err).To(MatchError(`failed: value for header 'Referrer-Policy' "no-referrer\"" must not contain quotes, backslashes, control characters or '${'`))
+				})
+			})
+
+			context("when the binding resolver fails", func() {
+				it.Before(func() {
+					bindingResolver.ResolveCall.Returns.Error = errors.New("failed to resolve binding")
+				})
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+					Expect(err).To(MatchError("failed to resolve binding"))
+				})
+			})
+
+			context("when more than one binding protects the web server root", func() {
+				it.Before(func() {
+					bindingResolver.ResolveCall.Stub = func(typ, provider, platformDir string) ([]servicebindings.Binding, error) {
+						if typ != "htpasswd" {
+							return nil, nil
+						}
+
+						return []servicebindings.Binding{
+							{
+								Name: "first",
+								Type: "htpasswd",
+								Path: "some-binding-path",
+								Entries: map[string]*servicebindings.Entry{
+									".htpasswd": servicebindings.NewEntry("some-path"),
+								},
+							},
+							{
+								Name: "second",
+								Type: "htpasswd",
+								Path: "some-binding-path",
+								Entries: map[string]*servicebindings.Entry{
+									".htpasswd": servicebindings.NewEntry("some-path"),
+								},
+							},
+						}, nil
+					}
+				})
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+					Expect(err).To(MatchError("failed: bindings 'first' and 'second' of type 'htpasswd' both protect the web server root"))
+				})
+			})
+
+			context("when a binding with path '/' and a binding without a path protect the web server root", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "path"), []byte("/\n"), 0600)).To(Succeed())
+
+					bindingResolver.ResolveCall.Stub = func(typ, provider, platformDir string) ([]servicebindings.Binding, error) {
+						if typ != "htpasswd" {
+							return nil, nil
+						}
+
+						return []servicebindings.Binding{
+							{
+								Name: "first",
+								Type: "htpasswd",
+								Path: "some-binding-path",
+								Entries: map[string]*servicebindings.Entry{
+									".htpasswd": servicebindings.NewEntry("some-path"),
+									"path":      servicebindings.NewEntry(filepath.Join(workingDir, "path")),
+								},
+							},
+							{
+								Name: "second",
+								Type: "htpasswd",
+								Path: "some-binding-path",
+								Entries: map[string]*servicebindings.Entry{
+									".htpasswd": servicebindings.NewEntry("some-path"),
+								},
+							},
+						}, nil
+					}
+				})
+
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+					Expect(err).To(MatchError("failed: bindings 'first' and 'second' of type 'htpasswd' both protect the web server root"))
+				})
+			})
+
+			context("when an htpasswd binding has an invalid path", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "path"), []byte("admin"), 0600)).To(Succeed())
+
+					bindingResolver.ResolveCall.Stub = func(typ, provider, platformDir string) ([]servicebindings.Binding, error) {
+						if typ != "htpasswd" {
+							return nil, nil
+						}
+
+						return []servicebindings.Binding{
+							{
+								Name: "first",
+								Type: "htpasswd",
+								Path: "some-binding-path",
+								Entries: map[string]*servicebindings.Entry{
+									".htpasswd": servicebindings.NewEntry("some-path"),
+									"path":      servicebindings.NewEntry(filepath.Join(workingDir, "path")),
+								},
+							},
+						}, nil
+					}
+				})
+
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+					Expect(err).To(MatchError("failed: binding 'first' of type 'htpasswd' has invalid path 'admin'"))
+				})
+			})
+
+			context("when two htpasswd bindings protect the same path", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "path"), []byte("/admin\n"), 0600)).To(Succeed())
+
+					bindingResolver.ResolveCall.Stub = func(typ, provider, platformDir string) ([]servicebindings.Binding, error) {
+						if typ != "htpasswd" {
+							return nil, nil
+						}
+
+						return []servicebindings.Binding{
+							{
+								Name: "first",
+								Type: "htpasswd",
+								Entries: map[string]*servicebindings.Entry{
+									".htpasswd": servicebindings.NewEntry("some-path"),
+									"path":      servicebindings.NewEntry(filepath.Join(workingDir, "path")),
+								},
+							},
+							{
+								Name: "second",
+								Type: "htpasswd",
+								Entries: map[string]*servicebindings.Entry{
+									".htpasswd": servicebindings.NewEntry("some-path"),
+									"path":      servicebindings.NewEntry(filepath.Join(workingDir, "path")),
+								},
+							},
+						}, nil
+					}
+				})
+
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+					Expect(err).To(MatchError("failed: bindings 'first' and 'second' of type 'htpasswd' both protect path '/admin'"))
+				})
+			})
+
+			context("when an httpd-config binding has no snippets", func() {
+				it.Before(func() {
+					bindingResolver.ResolveCall.Stub = func(typ, provider, platformDir string) ([]servicebindings.Binding, error) {
+						if typ != "httpd-config" {
+							return nil, nil
+						}
+
+						return []servicebindings.Binding{
+							{
+								Name:    "first",
+								Type:    "httpd-config",
+								Path:    "some-binding-path",
+								Entries: map[string]*servicebindings.Entry{},
+							},
+						}, nil
+					}
+				})
+
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+					Expect(err).To(MatchError("failed: binding 'first' of type 'httpd-config' does not contain any *.conf entries"))
+				})
+			})
+
+			context("when BP_WEB_SERVER_ERROR_PAGES is invalid", func() {
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerErrorPages: "302=/moved.html"})
+					Expect(err).To(MatchError(`failed to parse error page "302=/moved.html": expected <status>=<path> with a 4xx or 5xx status`))
+				})
+
+				it("returns an error for a relative path", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerErrorPages: "404=404.html"})
+					Expect(err).To(MatchError(`failed to parse error page "404=404.html": path "404.html" must start with '/' and must not contain whitespace, quotes, backslashes or '${'`))
+				})
+			})
+
+			context("when more than one tls binding is found", func() {
+				it.Before(func() {
+					bindingResolver.ResolveCall.Stub = func(typ, provider, platformDir string) ([]servicebindings.Binding, error) {
+						if typ != "tls" {
+							return nil, nil
+						}
+
+						return []servicebindings.Binding{
+							{Name: "first", Type: "tls"},
+							{Name: "second", Type: "tls"},
+						}, nil
+					}
+				})
+
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+					Expect(err).To(MatchError("failed: binding resolver found more than one binding of type 'tls'"))
+				})
+			})
+
+			context("when the tls binding is missing a required entry", func() {
+				it.Before(func() {
+					bindingResolver.ResolveCall.Stub = func(typ, provider, platformDir string) ([]servicebindings.Binding, error) {
+						if typ != "tls" {
+							return nil, nil
+						}
+
+						return []servicebindings.Binding{
+							{
+								Name: "first",
+								Type: "tls",
+								Path: "some-binding-path",
+								Entries: map[string]*servicebindings.Entry{
+									"tls.crt": servicebindings.NewEntry("some-path"),
+								},
+							},
+						}, nil
+					}
+				})
+
+				it("returns an error", func() {
+					err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+					Expect(err).To(MatchError("failed: binding of type 'tls' does not contain required entry 'tls.key'"))
+				})
+
+				context("when the TLS port is invalid", func() {
+					it.Before(func() {
+						bindingResolver.ResolveCall.Stub = func(typ, provider, platformDir string) ([]servicebindings.Binding, error) {
+							if typ != "tls" {
+								return nil, nil
+							}
+
+							return []servicebindings.Binding{
+								{
+									Name: "first",
+									Type: "tls",
+									Path: "some-binding-path",
+									Entries: map[string]*servicebindings.Entry{
+										"tls.crt": servicebindings.NewEntry("some-path"),
+										"tls.key": servicebindings.NewEntry("some-path"),
+									},
+								},
+							}, nil
+						}
+					})
+
+					it("returns an error", func() {
+						err := generateHTTPDConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{WebServerTLSPort: "https"})
+						Expect(err).To(MatchError("failed: BP_WEB_SERVER_TLS_PORT 'https' is not a valid port"))
+					})
+				})
+			})
+
+			context("when the binding is missing the required entry", func() {
+				it.Before(func() {
+					bindingResolver.ResolveCall.Stub = func(typ, provider, platformDir string) ([]servicebindings.Binding, error) {
+						if typ != "htpasswd" {
+							return nil, nil
+						}
+
+						return []servicebindings.Binding{
+							{
+								Name: "first",
+								Type: "htpasswd",
+								Path: "some-binding-path",
+								Entries: map[string]*servicebindings.Entry{
+									"wrong-entry": servicebindings.NewEntry("some-path"),
+								},
+							},
+						}, nil
 					}
 				})
 				it("returns an error", func() {
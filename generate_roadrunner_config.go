@@ -0,0 +1,84 @@
+package httpd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/template"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+type GenerateRoadRunnerConfig struct {
+	logger scribe.Emitter
+}
+
+func NewGenerateRoadRunnerConfig(logger scribe.Emitter) GenerateRoadRunnerConfig {
+	return GenerateRoadRunnerConfig{
+		logger: logger,
+	}
+}
+
+func (g GenerateRoadRunnerConfig) Generate(workingDir, platformPath string, buildEnvironment BuildEnvironment) error {
+	g.logger.Process("Generating .rr.yaml")
+
+	t, err := template.New(".rr.yaml").Funcs(template.FuncMap{"quote": strconv.Quote}).Parse(roadRunnerConf)
+	if err != nil {
+		return err
+	}
+
+	if buildEnvironment.RoadRunnerNumWorkers < 0 {
+		return fmt.Errorf("failed: BP_ROADRUNNER_NUM_WORKERS %d must not be negative", buildEnvironment.RoadRunnerNumWorkers)
+	}
+
+	if buildEnvironment.RoadRunnerMaxJobs < 0 {
+		return fmt.Errorf("failed: BP_ROADRUNNER_MAX_JOBS %d must not be negative", buildEnvironment.RoadRunnerMaxJobs)
+	}
+
+	if buildEnvironment.RoadRunnerWorkerCommand == "" {
+		buildEnvironment.RoadRunnerWorkerCommand = "php worker.php"
+	} else {
+		g.logger.Subprocess("Adds configuration to set worker command to '%s'", buildEnvironment.RoadRunnerWorkerCommand)
+	}
+
+	if buildEnvironment.RoadRunnerHTTPAddress == "" {
+		buildEnvironment.RoadRunnerHTTPAddress = "0.0.0.0:${PORT}"
+	} else {
+		g.logger.Subprocess("Adds configuration to set HTTP address to '%s'", buildEnvironment.RoadRunnerHTTPAddress)
+	}
+
+	if buildEnvironment.RoadRunnerNumWorkers > 0 {
+		g.logger.Subprocess("Adds configuration to set worker pool size to %d", buildEnvironment.RoadRunnerNumWorkers)
+	}
+
+	if buildEnvironment.RoadRunnerMaxJobs > 0 {
+		g.logger.Subprocess("Adds configuration to set max jobs per worker to %d", buildEnvironment.RoadRunnerMaxJobs)
+	}
+
+	if buildEnvironment.RoadRunnerStaticDir != "" {
+		g.logger.Subprocess("Adds configuration that serves static files from '%s'", buildEnvironment.RoadRunnerStaticDir)
+	}
+
+	g.logger.Break()
+
+	confPath := filepath.Join(workingDir, ".rr.yaml")
+	confFile, err := os.Create(confPath)
+	if err != nil {
+		return err
+	}
+
+	err = t.Execute(confFile, buildEnvironment)
+	if err != nil {
+		confFile.Close()
+		os.Remove(confPath)
+		return err
+	}
+
+	err = confFile.Close()
+	if err != nil {
+		os.Remove(confPath)
+		return err
+	}
+	return nil
+}
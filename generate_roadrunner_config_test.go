@@ -0,0 +1,145 @@
+package httpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/httpd"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testGenerateRoadRunnerConfig(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		generateRoadRunnerConfig httpd.GenerateRoadRunnerConfig
+
+		buffer *bytes.Buffer
+	)
+
+	it.Before(func() {
+		buffer = bytes.NewBuffer(nil)
+
+		generateRoadRunnerConfig = httpd.NewGenerateRoadRunnerConfig(scribe.NewEmitter(buffer))
+	})
+
+	context("Generate", func() {
+		var (
+			workingDir string
+		)
+		it.Before(func() {
+			var err error
+			workingDir, err = os.MkdirTemp("", "working-dir")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(workingDir)).To(Succeed())
+		})
+
+		it("create a default .rr.yaml", func() {
+			err := generateRoadRunnerConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).To(ContainSubstring("Generating .rr.yaml"))
+
+			contents, err := os.ReadFile(filepath.Join(workingDir, ".rr.yaml"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(contents)).To(Equal(`version: "3"
+
+server:
+  command: "php worker.php"
+
+http:
+  address: "0.0.0.0:${PORT}"
+
+logs:
+  mode: production
+  output: stderr
+`), string(contents))
+		})
+
+		context("when the RoadRunner variables are set", func() {
+			it("creates a config with the worker, pool and static settings", func() {
+				err := generateRoadRunnerConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{
+					RoadRunnerWorkerCommand: `php vendor/bin/roadrunner-worker --name "app"`,
+					RoadRunnerHTTPAddress:   "127.0.0.1:${PORT}",
+					RoadRunnerNumWorkers:    4,
+					RoadRunnerMaxJobs:       64,
+					RoadRunnerStaticDir:     "public",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring(`Adds configuration to set worker command to 'php vendor/bin/roadrunner-worker --name "app"'`))
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration to set HTTP address to '127.0.0.1:${PORT}'"))
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration to set worker pool size to 4"))
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration to set max jobs per worker to 64"))
+				Expect(buffer.String()).To(ContainSubstring("Adds configuration that serves static files from 'public'"))
+
+				contents, err := os.ReadFile(filepath.Join(workingDir, ".rr.yaml"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(Equal(`version: "3"
+
+server:
+  command: "php vendor/bin/roadrunner-worker --name \"app\""
+
+http:
+  address: "127.0.0.1:${PORT}"
+  middleware: ["static"]
+  static:
+    dir: "public"
+    forbid: [".php", ".htaccess"]
+  pool:
+    num_workers: 4
+    max_jobs: 64
+
+logs:
+  mode: production
+  output: stderr
+`), string(contents))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the config file cannot be created", func() {
+				it.Before(func() {
+					Expect(os.Chmod(workingDir, 0000)).To(Succeed())
+				})
+
+				it.After(func() {
+					Expect(os.Chmod(workingDir, os.ModePerm)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					err := generateRoadRunnerConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{})
+					Expect(err).To(MatchError(ContainSubstring("permission denied")))
+				})
+			})
+
+			context("when BP_ROADRUNNER_NUM_WORKERS is negative", func() {
+				it("returns an error and does not create the config file", func() {
+					err := generateRoadRunnerConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{RoadRunnerNumWorkers: -1})
+					Expect(err).To(MatchError("failed: BP_ROADRUNNER_NUM_WORKERS -1 must not be negative"))
+
+					Expect(filepath.Join(workingDir, ".rr.yaml")).NotTo(BeAnExistingFile())
+				})
+			})
+
+			context("when BP_ROADRUNNER_MAX_JOBS is negative", func() {
+				it("returns an error and does not create the config file", func() {
+					err := generateRoadRunnerConfig.Generate(workingDir, "platform", httpd.BuildEnvironment{RoadRunnerMaxJobs: -5})
+					Expect(err).To(MatchError("failed: BP_ROADRUNNER_MAX_JOBS -5 must not be negative"))
+
+					Expect(filepath.Join(workingDir, ".rr.yaml")).NotTo(BeAnExistingFile())
+				})
+			})
+		})
+	})
+}
@@ -5,6 +5,7 @@ go 1.18
 require (
 	github.com/BurntSushi/toml v1.3.2
 	github.com/Masterminds/semver v1.5.0
+	github.com/andybalholm/brotli v1.0.6
 	github.com/caarlos0/env/v6 v6.10.1
 	github.com/onsi/gomega v1.30.0
 	github.com/paketo-buildpacks/occam v0.18.0
@@ -33,7 +34,6 @@ require (
 	github.com/anchore/packageurl-go v0.1.1-0.20230104203445-02e0a6721501 // indirect
 	github.com/anchore/stereoscope v0.0.0-20230412183729-8602f1afc574 // indirect
 	github.com/anchore/syft v0.80.0 // indirect
-	github.com/andybalholm/brotli v1.0.6 // indirect
 	github.com/apex/log v1.9.0 // indirect
 	github.com/becheran/wildmatch-go v1.0.0 // indirect
 	github.com/bmatcuk/doublestar/v4 v4.6.1 // indirect
@@ -0,0 +1,63 @@
+package httpd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// HealthCheckFile is the file in the launch config layer that is served at
+// BP_WEB_SERVER_HEALTH_CHECK_PATH so that health checks do not depend on the
+// web server root.
+const HealthCheckFile = "health.txt"
+
+// defaultStatusAllow are the clients allowed to read the server status when
+// BP_WEB_SERVER_STATUS_ALLOW is not set.
+var defaultStatusAllow = []string{"127.0.0.1", "::1"}
+
+// endpointPath validates the path of an endpoint set by the variable name.
+func endpointPath(name, value string) (string, error) {
+	path := strings.TrimSuffix(value, "/")
+	if !strings.HasPrefix(path, "/") || strings.IndexFunc(path, unicode.IsSpace) >= 0 || validateConfigValue(name, path) != nil {
+		return "", fmt.Errorf("failed: %s %q must be a path below '/' without whitespace, quotes, backslashes or '${'", name, value)
+	}
+
+	return path, nil
+}
+
+// statusAllow returns the IP addresses and CIDR ranges in value, which are
+// separated by commas or whitespace.
+func statusAllow(value string) ([]string, error) {
+	entries := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	if len(entries) == 0 {
+		return defaultStatusAllow, nil
+	}
+
+	for _, entry := range entries {
+		if net.ParseIP(entry) != nil {
+			continue
+		}
+
+		if _, _, err := net.ParseCIDR(entry); err != nil {
+			return nil, fmt.Errorf("failed: BP_WEB_SERVER_STATUS_ALLOW entry %q is not an IP address or CIDR range", entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// statusPathPattern returns the per-directory rewrite pattern that matches
+// requests for the status endpoint at path.
+func statusPathPattern(path string) string {
+	return fmt.Sprintf("^%s$", regexp.QuoteMeta(strings.TrimPrefix(path, "/")))
+}
+
+func writeHealthCheckFile(layerPath string) error {
+	return os.WriteFile(filepath.Join(layerPath, HealthCheckFile), []byte("ok\n"), 0644)
+}
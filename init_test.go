@@ -9,9 +9,16 @@ import (
 
 func TestUnitHTTPD(t *testing.T) {
 	suite := spec.New("httpd", spec.Report(report.Terminal{}))
+	suite("AssetPrecompressor", testAssetPrecompressor)
 	suite("Build", testBuild)
+	suite("ComposerDependencyParser", testComposerDependencyParser)
 	suite("Detect", testDetect)
 	suite("GenerateHTTPDConfig", testGenerateHTTPDConfig)
+	suite("GenerateRoadRunnerConfig", testGenerateRoadRunnerConfig)
+	suite("HTTPDConfigChecker", testHTTPDConfigChecker)
+	suite("HTTPDConfigLinter", testHTTPDConfigLinter)
+	suite("LaunchConfigRenderer", testLaunchConfigRenderer)
+	suite("MPMTuner", testMPMTuner)
 	suite("VersionParser", testVersionParser)
 	suite.Run(t)
 }
@@ -190,6 +190,7 @@ func testZeroConfig(t *testing.T, context spec.G, it spec.S) {
 				response, err := client.Get(fmt.Sprintf("http://localhost:%s", container.HostPort("8080")))
 				Expect(err).NotTo(HaveOccurred())
 				Expect(response.StatusCode).To(Equal(http.StatusMovedPermanently))
+				Expect(response.Header.Get("X-Request-ID")).NotTo(BeEmpty())
 
 				contents, err := io.ReadAll(response.Body)
 				Expect(err).NotTo(HaveOccurred())
@@ -241,6 +242,7 @@ func testZeroConfig(t *testing.T, context spec.G, it spec.S) {
 			response, err := http.Get(fmt.Sprintf("http://localhost:%s", container.HostPort("8080")))
 			Expect(err).NotTo(HaveOccurred())
 			Expect(response.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(response.Header.Get("X-Request-ID")).NotTo(BeEmpty())
 
 			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost:%s", container.HostPort("8080")), http.NoBody)
 			Expect(err).NotTo(HaveOccurred())
@@ -0,0 +1,166 @@
+package httpd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/caarlos0/env/v6"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+const (
+	// LaunchConfigLayer is the layer that holds the exec.d programs and files
+	// that httpd uses at launch.
+	LaunchConfigLayer = "launch-config"
+
+	// BuildEnvironmentFile is the file in the launch config layer that records
+	// the build environment the zero-config httpd.conf was generated from.
+	BuildEnvironmentFile = "build-environment.json"
+
+	// LaunchConfigExecutable is the exec.d executable that renders the
+	// zero-config httpd.conf again when the container starts.
+	LaunchConfigExecutable = "render-config"
+)
+
+// buildOnlyVariables are the BP_WEB_SERVER_* variables that cannot be changed
+// at launch because they select the web server or require work at build time.
+var buildOnlyVariables = map[string]bool{
+	"BP_WEB_SERVER":                       true,
+	"BP_WEB_SERVER_ENABLE_PRECOMPRESSION": true,
+}
+
+// LaunchConfigRenderer renders the zero-config httpd.conf at launch from the
+// build environment recorded in the launch config layer, overridden by the
+// WEB_SERVER_* variables of the runtime environment.
+type LaunchConfigRenderer struct {
+	generateConfig GenerateConfig
+	logger         scribe.Emitter
+}
+
+func NewLaunchConfigRenderer(generateConfig GenerateConfig, logger scribe.Emitter) LaunchConfigRenderer {
+	return LaunchConfigRenderer{
+		generateConfig: generateConfig,
+		logger:         logger,
+	}
+}
+
+// Render generates the httpd.conf in appRoot again when environ sets any
+// WEB_SERVER_* variable that has a BP_WEB_SERVER_* counterpart. Otherwise the
+// httpd.conf generated at build time is left untouched.
+func (r LaunchConfigRenderer) Render(layerPath, appRoot, platformPath string, environ []string) error {
+	overrides := launchOverrides(environ)
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	content, err := os.ReadFile(filepath.Join(layerPath, BuildEnvironmentFile))
+	if err != nil {
+		return fmt.Errorf("failed to read build environment: %w", err)
+	}
+
+	var buildEnvironment BuildEnvironment
+	err = json.Unmarshal(content, &buildEnvironment)
+	if err != nil {
+		return fmt.Errorf("failed to parse build environment: %w", err)
+	}
+
+	// The httpd.conf rendered at launch uses the service bindings that were
+	// resolved at build time, which must still be mounted at the same paths.
+	for _, path := range bindingFiles(buildEnvironment) {
+		_, err = os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed: '%s' from a service binding used at build time is not available at launch: %w", path, err)
+		}
+	}
+
+	var names []string
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	r.logger.Process("Applying launch environment to httpd.conf")
+	for _, name := range names {
+		r.logger.Subprocess("%s overrides %s", strings.TrimPrefix(name, "BP_"), name)
+	}
+	r.logger.Break()
+
+	err = env.Parse(&buildEnvironment, env.Options{Environment: overrides})
+	if err != nil {
+		return fmt.Errorf("failed to parse launch configuration: %w", err)
+	}
+
+	buildEnvironment.WebServerRoot, err = resolveWebServerRoot(appRoot, buildEnvironment.WebServerRoot)
+	if err != nil {
+		return err
+	}
+
+	return r.generateConfig.Generate(appRoot, platformPath, buildEnvironment)
+}
+
+// writeBuildEnvironment records buildEnvironment in the layer at layerPath
+// for LaunchConfigRenderer.
+func writeBuildEnvironment(layerPath string, buildEnvironment BuildEnvironment) error {
+	content, err := json.Marshal(buildEnvironment)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(layerPath, BuildEnvironmentFile), content, 0644)
+}
+
+// bindingFiles returns the files from service bindings that the httpd.conf
+// generated from buildEnvironment references.
+func bindingFiles(buildEnvironment BuildEnvironment) []string {
+	var files []string
+	for _, file := range []string{
+		buildEnvironment.TLSCertificateFile,
+		buildEnvironment.TLSKeyFile,
+		buildEnvironment.TLSCAFile,
+		buildEnvironment.BasicAuthFile,
+	} {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+
+	for _, location := range buildEnvironment.BasicAuthLocations {
+		files = append(files, location.File)
+	}
+
+	for _, include := range buildEnvironment.ConfigIncludes {
+		if filepath.IsAbs(include) {
+			files = append(files, include)
+		}
+	}
+
+	return files
+}
+
+// launchOverrides maps the WEB_SERVER_* variables in environ to the
+// BP_WEB_SERVER_* variables of BuildEnvironment that can be set at launch.
+func launchOverrides(environ []string) map[string]string {
+	variables := map[string]bool{}
+	fields := reflect.TypeOf(BuildEnvironment{})
+	for i := 0; i < fields.NumField(); i++ {
+		name := fields.Field(i).Tag.Get("env")
+		if strings.HasPrefix(name, "BP_WEB_SERVER_") && !buildOnlyVariables[name] {
+			variables[name] = true
+		}
+	}
+
+	overrides := map[string]string{}
+	for _, variable := range environ {
+		name, value, _ := strings.Cut(variable, "=")
+		if variables["BP_"+name] {
+			overrides["BP_"+name] = value
+		}
+	}
+
+	return overrides
+}
@@ -0,0 +1,201 @@
+package httpd_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/httpd"
+	"github.com/paketo-buildpacks/httpd/fakes"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testLaunchConfigRenderer(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerPath string
+		appRoot   string
+		buffer    *bytes.Buffer
+
+		generateConfig *fakes.GenerateConfig
+		renderer       httpd.LaunchConfigRenderer
+	)
+
+	it.Before(func() {
+		var err error
+		layerPath, err = os.MkdirTemp("", "layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		appRoot, err = os.MkdirTemp("", "app-root")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(appRoot, "dist"), os.ModePerm)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(appRoot, "htdocs"), os.ModePerm)).To(Succeed())
+
+		Expect(os.WriteFile(filepath.Join(layerPath, "build-environment.json"), []byte(`{
+			"WebServer": "httpd",
+			"WebServerRoot": "dist",
+			"WebServerForceHTTPS": true,
+			"WebServerPushStateEnabled": true
+		}`), 0644)).To(Succeed())
+
+		buffer = bytes.NewBuffer(nil)
+		generateConfig = &fakes.GenerateConfig{}
+		renderer = httpd.NewLaunchConfigRenderer(generateConfig, scribe.NewEmitter(buffer))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerPath)).To(Succeed())
+		Expect(os.RemoveAll(appRoot)).To(Succeed())
+	})
+
+	context("Render", func() {
+		it("renders the httpd.conf with the launch variables over the build environment", func() {
+			err := renderer.Render(layerPath, appRoot, "platform", []string{
+				"PATH=/usr/bin",
+				"WEB_SERVER_FORCE_HTTPS=false",
+				"WEB_SERVER_ROOT=htdocs",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(generateConfig.GenerateCall.Receives.WorkingDir).To(Equal(appRoot))
+			Expect(generateConfig.GenerateCall.Receives.PlatformPath).To(Equal("platform"))
+			Expect(generateConfig.GenerateCall.Receives.BuildEnvironment).To(Equal(httpd.BuildEnvironment{
+				WebServer:                 "httpd",
+				WebServerRoot:             "htdocs",
+				WebServerPushStateEnabled: true,
+			}))
+
+			Expect(buffer.String()).To(ContainSubstring("Applying launch environment to httpd.conf"))
+			Expect(buffer.String()).To(ContainSubstring("WEB_SERVER_FORCE_HTTPS overrides BP_WEB_SERVER_FORCE_HTTPS"))
+			Expect(buffer.String()).To(ContainSubstring("WEB_SERVER_ROOT overrides BP_WEB_SERVER_ROOT"))
+		})
+
+		context("when no launch variable is set", func() {
+			it("keeps the httpd.conf generated at build time", func() {
+				err := renderer.Render(layerPath, appRoot, "platform", []string{"PATH=/usr/bin"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(generateConfig.GenerateCall.CallCount).To(Equal(0))
+				Expect(buffer.String()).To(BeEmpty())
+			})
+		})
+
+		context("when only build-time variables are set", func() {
+			it("keeps the httpd.conf generated at build time", func() {
+				err := renderer.Render(layerPath, appRoot, "platform", []string{
+					"WEB_SERVER=nginx",
+					"WEB_SERVER_ENABLE_PRECOMPRESSION=true",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(generateConfig.GenerateCall.CallCount).To(Equal(0))
+			})
+		})
+
+		context("when the build environment records files from service bindings", func() {
+			var bindingPath string
+
+			it.Before(func() {
+				var err error
+				bindingPath, err = os.MkdirTemp("", "binding")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(os.WriteFile(filepath.Join(bindingPath, ".htpasswd"), nil, 0600)).To(Succeed())
+
+				Expect(os.WriteFile(filepath.Join(layerPath, "build-environment.json"), []byte(fmt.Sprintf(`{
+					"WebServer": "httpd",
+					"BindingsResolved": true,
+					"BasicAuthFile": %q,
+					"BasicAuthRealm": "Authentication Required"
+				}`, filepath.Join(bindingPath, ".htpasswd"))), 0644)).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.RemoveAll(bindingPath)).To(Succeed())
+			})
+
+			it("renders the httpd.conf with the recorded files", func() {
+				err := renderer.Render(layerPath, appRoot, "platform", []string{"WEB_SERVER_FORCE_HTTPS=true"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(generateConfig.GenerateCall.Receives.BuildEnvironment).To(Equal(httpd.BuildEnvironment{
+					BasicAuthFile:       filepath.Join(bindingPath, ".htpasswd"),
+					BasicAuthRealm:      "Authentication Required",
+					BindingsResolved:    true,
+					WebServer:           "httpd",
+					WebServerForceHTTPS: true,
+				}))
+			})
+
+			context("when a recorded file is missing at launch", func() {
+				it.Before(func() {
+					Expect(os.Remove(filepath.Join(bindingPath, ".htpasswd"))).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					err := renderer.Render(layerPath, appRoot, "platform", []string{"WEB_SERVER_FORCE_HTTPS=true"})
+					Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("failed: '%s' from a service binding used at build time is not available at launch", filepath.Join(bindingPath, ".htpasswd")))))
+
+					Expect(generateConfig.GenerateCall.CallCount).To(Equal(0))
+				})
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the build environment cannot be read", func() {
+				it.Before(func() {
+					Expect(os.Remove(filepath.Join(layerPath, "build-environment.json"))).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					err := renderer.Render(layerPath, appRoot, "platform", []string{"WEB_SERVER_FORCE_HTTPS=true"})
+					Expect(err).To(MatchError(ContainSubstring("failed to read build environment")))
+				})
+			})
+
+			context("when the build environment is malformed", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(layerPath, "build-environment.json"), []byte("%%%"), 0644)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					err := renderer.Render(layerPath, appRoot, "platform", []string{"WEB_SERVER_FORCE_HTTPS=true"})
+					Expect(err).To(MatchError(ContainSubstring("failed to parse build environment")))
+				})
+			})
+
+			context("when a launch variable is malformed", func() {
+				it("returns an error", func() {
+					err := renderer.Render(layerPath, appRoot, "platform", []string{"WEB_SERVER_FORCE_HTTPS=sometimes"})
+					Expect(err).To(MatchError(ContainSubstring("failed to parse launch configuration")))
+				})
+			})
+
+			context("when the launch root does not exist", func() {
+				it("returns an error", func() {
+					err := renderer.Render(layerPath, appRoot, "platform", []string{"WEB_SERVER_ROOT=missing"})
+					Expect(err).To(MatchError(ContainSubstring(`BP_WEB_SERVER_ROOT "missing" does not exist`)))
+				})
+			})
+
+			context("when generating the config fails", func() {
+				it.Before(func() {
+					generateConfig.GenerateCall.Returns.Error = errors.New("failed to generate config")
+				})
+
+				it("returns an error", func() {
+					err := renderer.Render(layerPath, appRoot, "platform", []string{"WEB_SERVER_FORCE_HTTPS=true"})
+					Expect(err).To(MatchError("failed to generate config"))
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,318 @@
+package httpd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MPMTuningExecutable is the exec.d executable that sizes the event MPM for
+// the CPU and memory limits of the container when it starts.
+const MPMTuningExecutable = "tune-mpm"
+
+// The launch environment variables that the generated httpd.conf references
+// for the event MPM settings. Setting any of them explicitly overrides the
+// computed value.
+const (
+	ServerLimitVariable            = "HTTPD_SERVER_LIMIT"
+	ThreadsPerChildVariable        = "HTTPD_THREADS_PER_CHILD"
+	MaxRequestWorkersVariable      = "HTTPD_MAX_REQUEST_WORKERS"
+	MaxConnectionsPerChildVariable = "HTTPD_MAX_CONNECTIONS_PER_CHILD"
+)
+
+const (
+	// defaultServerLimit and defaultThreadsPerChild are the compiled-in
+	// defaults of the event MPM. Computed settings never exceed them.
+	defaultServerLimit     = 16
+	defaultThreadsPerChild = 25
+
+	// childProcessMemory and childThreadMemory estimate the resident memory
+	// of a child process and of each of its threads.
+	childProcessMemory = 8 << 20
+	childThreadMemory  = 1 << 20
+
+	// childrenPerCPU is the number of child processes allowed per CPU.
+	childrenPerCPU = 4
+
+	// limitedMaxConnectionsPerChild recycles children when memory is limited
+	// so that slow leaks cannot grow them until the container is killed.
+	limitedMaxConnectionsPerChild = 10000
+
+	// unlimitedMemory is the value above which a cgroup v1 memory limit is
+	// treated as unset.
+	unlimitedMemory = 1 << 62
+)
+
+// MPMSettings are the event MPM directives sized for a container.
+type MPMSettings struct {
+	ServerLimit            int
+	ThreadsPerChild        int
+	MaxRequestWorkers      int
+	MaxConnectionsPerChild int
+}
+
+// Environment returns the settings as the launch environment variables that
+// the generated httpd.conf references.
+func (s MPMSettings) Environment() map[string]string {
+	return map[string]string{
+		ServerLimitVariable:            strconv.Itoa(s.ServerLimit),
+		ThreadsPerChildVariable:        strconv.Itoa(s.ThreadsPerChild),
+		MaxRequestWorkersVariable:      strconv.Itoa(s.MaxRequestWorkers),
+		MaxConnectionsPerChildVariable: strconv.Itoa(s.MaxConnectionsPerChild),
+	}
+}
+
+// defaultMPMSettings are the settings httpd uses without any limits.
+var defaultMPMSettings = MPMSettings{
+	ServerLimit:            defaultServerLimit,
+	ThreadsPerChild:        defaultThreadsPerChild,
+	MaxRequestWorkers:      defaultServerLimit * defaultThreadsPerChild,
+	MaxConnectionsPerChild: 0,
+}
+
+// ContainerLimits are the CPU and memory limits of a container. A zero value
+// means that there is no limit.
+type ContainerLimits struct {
+	CPUs   int
+	Memory int64
+}
+
+type MPMTuner struct {
+	cgroupRoot string
+	cpuCount   int
+}
+
+// NewMPMTuner returns an MPMTuner that reads the cgroup v1 or v2 hierarchy
+// mounted at cgroupRoot and caps the CPU limit at cpuCount.
+func NewMPMTuner(cgroupRoot string, cpuCount int) MPMTuner {
+	return MPMTuner{
+		cgroupRoot: cgroupRoot,
+		cpuCount:   cpuCount,
+	}
+}
+
+// Tune computes the MPM settings for the limits of the container. The
+// variables in environ that set a setting explicitly always win.
+func (t MPMTuner) Tune(environ []string) (MPMSettings, ContainerLimits, error) {
+	limits, err := t.Limits()
+	if err != nil {
+		return MPMSettings{}, ContainerLimits{}, err
+	}
+
+	overrides := map[string]string{}
+	for _, variable := range environ {
+		name, value, _ := strings.Cut(variable, "=")
+		overrides[name] = value
+	}
+
+	settings, err := computeMPMSettings(limits, overrides)
+	if err != nil {
+		return MPMSettings{}, ContainerLimits{}, err
+	}
+
+	return settings, limits, nil
+}
+
+// Limits reads the CPU and memory limits of the container from the cgroup
+// hierarchy, preferring cgroup v2.
+func (t MPMTuner) Limits() (ContainerLimits, error) {
+	limits := ContainerLimits{CPUs: t.cpuCount}
+
+	quota, period, err := t.cpuQuota()
+	if err != nil {
+		return ContainerLimits{}, err
+	}
+
+	if quota > 0 && period > 0 {
+		cpus := int(math.Ceil(float64(quota) / float64(period)))
+		if limits.CPUs == 0 || cpus < limits.CPUs {
+			limits.CPUs = cpus
+		}
+	}
+
+	limits.Memory, err = t.memoryLimit()
+	if err != nil {
+		return ContainerLimits{}, err
+	}
+
+	return limits, nil
+}
+
+func (t MPMTuner) cpuQuota() (int64, int64, error) {
+	content, ok, err := readCgroupFile(filepath.Join(t.cgroupRoot, "cpu.max"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if ok {
+		fields := strings.Fields(content)
+		if len(fields) != 2 || fields[0] == "max" {
+			return 0, 0, nil
+		}
+
+		quota, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to parse cpu.max: %w", err)
+		}
+
+		period, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to parse cpu.max: %w", err)
+		}
+
+		return quota, period, nil
+	}
+
+	quota, err := readCgroupInt(filepath.Join(t.cgroupRoot, "cpu", "cpu.cfs_quota_us"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	period, err := readCgroupInt(filepath.Join(t.cgroupRoot, "cpu", "cpu.cfs_period_us"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return quota, period, nil
+}
+
+func (t MPMTuner) memoryLimit() (int64, error) {
+	content, ok, err := readCgroupFile(filepath.Join(t.cgroupRoot, "memory.max"))
+	if err != nil {
+		return 0, err
+	}
+
+	if ok {
+		if content == "max" {
+			return 0, nil
+		}
+
+		limit, err := strconv.ParseInt(content, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse memory.max: %w", err)
+		}
+
+		return limit, nil
+	}
+
+	limit, err := readCgroupInt(filepath.Join(t.cgroupRoot, "memory", "memory.limit_in_bytes"))
+	if err != nil {
+		return 0, err
+	}
+
+	if limit <= 0 || limit >= unlimitedMemory {
+		return 0, nil
+	}
+
+	return limit, nil
+}
+
+func readCgroupFile(path string) (string, bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	return strings.TrimSpace(string(content)), true, nil
+}
+
+func readCgroupInt(path string) (int64, error) {
+	content, ok, err := readCgroupFile(path)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	value, err := strconv.ParseInt(content, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+
+	return value, nil
+}
+
+// computeMPMSettings sizes the event MPM so that its children fit in three
+// quarters of the memory limit and number at most childrenPerCPU per CPU.
+// Explicit overrides win, and the settings that are not overridden are
+// derived from them.
+func computeMPMSettings(limits ContainerLimits, overrides map[string]string) (MPMSettings, error) {
+	settings := defaultMPMSettings
+
+	threadsPerChild, threadsSet, err := mpmOverride(overrides, ThreadsPerChildVariable, 1)
+	if err != nil {
+		return MPMSettings{}, err
+	}
+	if threadsSet {
+		settings.ThreadsPerChild = threadsPerChild
+	}
+
+	if limits.Memory > 0 {
+		children := int(limits.Memory * 3 / 4 / int64(childProcessMemory+settings.ThreadsPerChild*childThreadMemory))
+		if children < settings.ServerLimit {
+			settings.ServerLimit = children
+		}
+		settings.MaxConnectionsPerChild = limitedMaxConnectionsPerChild
+	}
+
+	if limits.CPUs > 0 {
+		if limits.CPUs*childrenPerCPU < settings.ServerLimit {
+			settings.ServerLimit = limits.CPUs * childrenPerCPU
+		}
+	}
+
+	if settings.ServerLimit < 1 {
+		settings.ServerLimit = 1
+	}
+
+	maxRequestWorkers, workersSet, err := mpmOverride(overrides, MaxRequestWorkersVariable, 1)
+	if err != nil {
+		return MPMSettings{}, err
+	}
+
+	serverLimit, serverLimitSet, err := mpmOverride(overrides, ServerLimitVariable, 1)
+	if err != nil {
+		return MPMSettings{}, err
+	}
+
+	switch {
+	case serverLimitSet:
+		settings.ServerLimit = serverLimit
+	case workersSet:
+		settings.ServerLimit = (maxRequestWorkers + settings.ThreadsPerChild - 1) / settings.ThreadsPerChild
+	}
+
+	settings.MaxRequestWorkers = settings.ServerLimit * settings.ThreadsPerChild
+	if workersSet {
+		settings.MaxRequestWorkers = maxRequestWorkers
+	}
+
+	maxConnectionsPerChild, connectionsSet, err := mpmOverride(overrides, MaxConnectionsPerChildVariable, 0)
+	if err != nil {
+		return MPMSettings{}, err
+	}
+	if connectionsSet {
+		settings.MaxConnectionsPerChild = maxConnectionsPerChild
+	}
+
+	return settings, nil
+}
+
+func mpmOverride(overrides map[string]string, name string, minimum int) (int, bool, error) {
+	value, ok := overrides[name]
+	if !ok || value == "" {
+		return 0, false, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < minimum {
+		return 0, false, fmt.Errorf("failed: %s %q must be an integer of at least %d", name, value, minimum)
+	}
+
+	return number, true, nil
+}
@@ -0,0 +1,206 @@
+package httpd_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testMPMTuner(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		cgroupRoot string
+		tuner      httpd.MPMTuner
+	)
+
+	it.Before(func() {
+		var err error
+		cgroupRoot, err = os.MkdirTemp("", "cgroup")
+		Expect(err).NotTo(HaveOccurred())
+
+		tuner = httpd.NewMPMTuner(cgroupRoot, 8)
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(cgroupRoot)).To(Succeed())
+	})
+
+	context("Tune", func() {
+		context("when there are no cgroup limits", func() {
+			it("caps the httpd defaults by the number of CPUs", func() {
+				settings, limits, err := tuner.Tune(nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(limits).To(Equal(httpd.ContainerLimits{CPUs: 8}))
+				Expect(settings).To(Equal(httpd.MPMSettings{
+					ServerLimit:            16,
+					ThreadsPerChild:        25,
+					MaxRequestWorkers:      400,
+					MaxConnectionsPerChild: 0,
+				}))
+			})
+		})
+
+		context("when cgroup v2 limits are set", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(cgroupRoot, "cpu.max"), []byte("150000 100000\n"), 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(cgroupRoot, "memory.max"), []byte("268435456\n"), 0600)).To(Succeed())
+			})
+
+			it("sizes the MPM for the limits", func() {
+				settings, limits, err := tuner.Tune(nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(limits).To(Equal(httpd.ContainerLimits{CPUs: 2, Memory: 256 << 20}))
+				Expect(settings).To(Equal(httpd.MPMSettings{
+					ServerLimit:            5,
+					ThreadsPerChild:        25,
+					MaxRequestWorkers:      125,
+					MaxConnectionsPerChild: 10000,
+				}))
+				Expect(settings.Environment()).To(Equal(map[string]string{
+					"HTTPD_SERVER_LIMIT":              "5",
+					"HTTPD_THREADS_PER_CHILD":         "25",
+					"HTTPD_MAX_REQUEST_WORKERS":       "125",
+					"HTTPD_MAX_CONNECTIONS_PER_CHILD": "10000",
+				}))
+			})
+
+			context("when the limits are max", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(cgroupRoot, "cpu.max"), []byte("max 100000\n"), 0600)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(cgroupRoot, "memory.max"), []byte("max\n"), 0600)).To(Succeed())
+				})
+
+				it("treats them as unlimited", func() {
+					_, limits, err := tuner.Tune(nil)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(limits).To(Equal(httpd.ContainerLimits{CPUs: 8}))
+				})
+			})
+		})
+
+		context("when cgroup v1 limits are set", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(cgroupRoot, "cpu"), os.ModePerm)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(cgroupRoot, "memory"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(cgroupRoot, "cpu", "cpu.cfs_quota_us"), []byte("50000\n"), 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(cgroupRoot, "cpu", "cpu.cfs_period_us"), []byte("100000\n"), 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(cgroupRoot, "memory", "memory.limit_in_bytes"), []byte("2147483648\n"), 0600)).To(Succeed())
+			})
+
+			it("sizes the MPM for the limits", func() {
+				settings, limits, err := tuner.Tune(nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(limits).To(Equal(httpd.ContainerLimits{CPUs: 1, Memory: 2 << 30}))
+				Expect(settings).To(Equal(httpd.MPMSettings{
+					ServerLimit:            4,
+					ThreadsPerChild:        25,
+					MaxRequestWorkers:      100,
+					MaxConnectionsPerChild: 10000,
+				}))
+			})
+
+			context("when the memory limit is the unlimited sentinel", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(cgroupRoot, "memory", "memory.limit_in_bytes"), []byte("9223372036854771712\n"), 0600)).To(Succeed())
+				})
+
+				it("treats it as unlimited", func() {
+					_, limits, err := tuner.Tune(nil)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(limits).To(Equal(httpd.ContainerLimits{CPUs: 1}))
+				})
+			})
+		})
+
+		context("when the memory limit is very small", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(cgroupRoot, "memory.max"), []byte("16777216\n"), 0600)).To(Succeed())
+			})
+
+			it("keeps at least one child", func() {
+				settings, _, err := tuner.Tune(nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(settings.ServerLimit).To(Equal(1))
+				Expect(settings.MaxRequestWorkers).To(Equal(25))
+			})
+		})
+
+		context("when settings are overridden", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(cgroupRoot, "memory.max"), []byte("268435456\n"), 0600)).To(Succeed())
+			})
+
+			it("uses the overrides and derives the other settings from them", func() {
+				settings, _, err := tuner.Tune([]string{
+					"HTTPD_THREADS_PER_CHILD=10",
+					"HTTPD_MAX_REQUEST_WORKERS=95",
+					"HTTPD_MAX_CONNECTIONS_PER_CHILD=0",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(settings).To(Equal(httpd.MPMSettings{
+					ServerLimit:            10,
+					ThreadsPerChild:        10,
+					MaxRequestWorkers:      95,
+					MaxConnectionsPerChild: 0,
+				}))
+			})
+
+			it("uses an overridden server limit", func() {
+				settings, _, err := tuner.Tune([]string{"HTTPD_SERVER_LIMIT=32"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(settings).To(Equal(httpd.MPMSettings{
+					ServerLimit:            32,
+					ThreadsPerChild:        25,
+					MaxRequestWorkers:      800,
+					MaxConnectionsPerChild: 10000,
+				}))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when an override is not a positive integer", func() {
+				it("returns an error", func() {
+					_, _, err := tuner.Tune([]string{"HTTPD_THREADS_PER_CHILD=0"})
+					Expect(err).To(MatchError(`failed: HTTPD_THREADS_PER_CHILD "0" must be an integer of at least 1`))
+				})
+			})
+
+			context("when cpu.max is malformed", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(cgroupRoot, "cpu.max"), []byte("lots 100000\n"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, _, err := tuner.Tune(nil)
+					Expect(err).To(MatchError(ContainSubstring("failed to parse cpu.max")))
+				})
+			})
+
+			context("when memory.limit_in_bytes is malformed", func() {
+				it.Before(func() {
+					Expect(os.MkdirAll(filepath.Join(cgroupRoot, "memory"), os.ModePerm)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(cgroupRoot, "memory", "memory.limit_in_bytes"), []byte("lots\n"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, _, err := tuner.Tune(nil)
+					Expect(err).To(MatchError(ContainSubstring("failed to parse memory.limit_in_bytes")))
+				})
+			})
+		})
+	})
+}
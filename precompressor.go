@@ -0,0 +1,123 @@
+package httpd
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/andybalholm/brotli"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// precompressExtensions must be kept in sync with the extensions matched by
+// the precompressed content rules in default_conf.go.
+var precompressExtensions = map[string]bool{
+	".css":  true,
+	".html": true,
+	".js":   true,
+	".json": true,
+	".map":  true,
+	".mjs":  true,
+	".svg":  true,
+	".txt":  true,
+	".wasm": true,
+	".xml":  true,
+}
+
+type AssetPrecompressor struct {
+	logger scribe.Emitter
+}
+
+func NewAssetPrecompressor(logger scribe.Emitter) AssetPrecompressor {
+	return AssetPrecompressor{
+		logger: logger,
+	}
+}
+
+func (a AssetPrecompressor) Precompress(root string) error {
+	a.logger.Process("Precompressing static assets in %s", root)
+
+	var count int
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root && errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		if !entry.Type().IsRegular() || !precompressExtensions[filepath.Ext(path)] {
+			return nil
+		}
+
+		for _, encoding := range []struct {
+			extension string
+			writer    func(io.Writer) io.WriteCloser
+		}{
+			{".gz", func(w io.Writer) io.WriteCloser {
+				gw, _ := gzip.NewWriterLevel(w, gzip.BestCompression)
+				return gw
+			}},
+			{".br", func(w io.Writer) io.WriteCloser {
+				return brotli.NewWriterLevel(w, brotli.BestCompression)
+			}},
+		} {
+			written, err := compressFile(path, path+encoding.extension, encoding.writer)
+			if err != nil {
+				return err
+			}
+
+			if written {
+				count++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	a.logger.Subprocess("Wrote %d precompressed files", count)
+	a.logger.Break()
+
+	return nil
+}
+
+func compressFile(source, destination string, newWriter func(io.Writer) io.WriteCloser) (bool, error) {
+	_, err := os.Stat(destination)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+
+	in, err := os.Open(source)
+	if err != nil {
+		return false, err
+	}
+	defer in.Close()
+
+	out, err := os.Create(destination)
+	if err != nil {
+		return false, err
+	}
+	defer out.Close()
+
+	writer := newWriter(out)
+	_, err = io.Copy(writer, in)
+	if err != nil {
+		return false, err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return false, err
+	}
+
+	return true, out.Close()
+}
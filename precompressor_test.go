@@ -0,0 +1,120 @@
+package httpd_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/paketo-buildpacks/httpd"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testAssetPrecompressor(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		root   string
+		buffer *bytes.Buffer
+
+		precompressor httpd.AssetPrecompressor
+	)
+
+	it.Before(func() {
+		var err error
+		root, err = os.MkdirTemp("", "root")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(root, "assets"), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, "index.html"), []byte("<html>some-html</html>"), 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, "assets", "app.js"), []byte("console.log('some-js')"), 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, "assets", "logo.png"), []byte("some-png"), 0600)).To(Succeed())
+
+		buffer = bytes.NewBuffer(nil)
+		precompressor = httpd.NewAssetPrecompressor(scribe.NewEmitter(buffer))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(root)).To(Succeed())
+	})
+
+	context("Precompress", func() {
+		it("writes gzip and brotli siblings for compressible files", func() {
+			err := precompressor.Precompress(root)
+			Expect(err).NotTo(HaveOccurred())
+
+			file, err := os.Open(filepath.Join(root, "index.html.gz"))
+			Expect(err).NotTo(HaveOccurred())
+			defer file.Close()
+
+			gzipReader, err := gzip.NewReader(file)
+			Expect(err).NotTo(HaveOccurred())
+
+			content, err := io.ReadAll(gzipReader)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("<html>some-html</html>"))
+
+			file, err = os.Open(filepath.Join(root, "assets", "app.js.br"))
+			Expect(err).NotTo(HaveOccurred())
+			defer file.Close()
+
+			content, err = io.ReadAll(brotli.NewReader(file))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("console.log('some-js')"))
+
+			Expect(filepath.Join(root, "assets", "logo.png.gz")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(root, "assets", "logo.png.br")).NotTo(BeAnExistingFile())
+
+			Expect(buffer.String()).To(ContainSubstring("Wrote 4 precompressed files"))
+		})
+
+		context("when a sibling already exists", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(root, "index.html.br"), []byte("some-existing-br"), 0600)).To(Succeed())
+			})
+
+			it("does not overwrite it", func() {
+				err := precompressor.Precompress(root)
+				Expect(err).NotTo(HaveOccurred())
+
+				content, err := os.ReadFile(filepath.Join(root, "index.html.br"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(Equal("some-existing-br"))
+
+				Expect(buffer.String()).To(ContainSubstring("Wrote 3 precompressed files"))
+			})
+		})
+
+		context("when the root does not exist", func() {
+			it("does nothing", func() {
+				err := precompressor.Precompress(filepath.Join(root, "missing"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Wrote 0 precompressed files"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when a sibling cannot be written", func() {
+				it.Before(func() {
+					Expect(os.Chmod(filepath.Join(root, "assets"), 0500)).To(Succeed())
+				})
+
+				it.After(func() {
+					Expect(os.Chmod(filepath.Join(root, "assets"), os.ModePerm)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					err := precompressor.Precompress(root)
+					Expect(err).To(MatchError(ContainSubstring("permission denied")))
+				})
+			})
+		})
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/paketo-buildpacks/packit/v2/cargo"
 	"github.com/paketo-buildpacks/packit/v2/chronos"
 	"github.com/paketo-buildpacks/packit/v2/draft"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
 	"github.com/paketo-buildpacks/packit/v2/postal"
 	"github.com/paketo-buildpacks/packit/v2/sbom"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
@@ -28,8 +29,14 @@ func main() {
 	dependencyService := postal.NewService(transport)
 	logEmitter := scribe.NewEmitter(os.Stdout).WithLevel(os.Getenv("BP_LOG_LEVEL"))
 	versionParser := httpd.NewVersionParser()
+	composerParser := httpd.NewComposerDependencyParser()
 	entryResolver := draft.NewPlanner()
-	generateHTTPDConfig := httpd.NewGenerateHTTPDConfig(servicebindings.NewResolver(), logEmitter)
+	bindingResolver := servicebindings.NewResolver()
+	generateHTTPDConfig := httpd.NewGenerateHTTPDConfig(bindingResolver, logEmitter)
+	generateRoadRunnerConfig := httpd.NewGenerateRoadRunnerConfig(logEmitter)
+	precompressor := httpd.NewAssetPrecompressor(logEmitter)
+	configChecker := httpd.NewHTTPDConfigChecker(pexec.NewExecutable("httpd"), logEmitter)
+	configLinter := httpd.NewHTTPDConfigLinter(logEmitter)
 
 	var buildEnvironment httpd.BuildEnvironment
 	err := env.Parse(&buildEnvironment)
@@ -42,12 +49,19 @@ func main() {
 		httpd.Detect(
 			buildEnvironment,
 			versionParser,
+			composerParser,
+			logEmitter,
 		),
 		httpd.Build(
 			buildEnvironment,
 			entryResolver,
 			dependencyService,
+			bindingResolver,
 			generateHTTPDConfig,
+			generateRoadRunnerConfig,
+			precompressor,
+			configChecker,
+			configLinter,
 			Generator{},
 			chronos.DefaultClock,
 			logEmitter,
@@ -0,0 +1,81 @@
+package httpd
+
+import (
+	"fmt"
+)
+
+// SecurityHeader is a response header that is set on every response,
+// including error responses.
+type SecurityHeader struct {
+	Name  string
+	Value string
+}
+
+var securityHeaderPresets = map[string]map[string]string{
+	"off": {},
+	"basic": {
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "SAMEORIGIN",
+		"Referrer-Policy":           "strict-origin-when-cross-origin",
+		"Strict-Transport-Security": "max-age=31536000",
+	},
+	"strict": {
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "DENY",
+		"Referrer-Policy":           "no-referrer",
+		"Content-Security-Policy":   "default-src 'self'; base-uri 'self'; form-action 'self'; frame-ancestors 'none'",
+		"Strict-Transport-Security": "max-age=63072000; includeSubDomains",
+	},
+}
+
+var securityHeaderOrder = []string{
+	"Strict-Transport-Security",
+	"Content-Security-Policy",
+	"X-Frame-Options",
+	"X-Content-Type-Options",
+	"Referrer-Policy",
+}
+
+func securityHeaders(buildEnvironment BuildEnvironment) ([]SecurityHeader, error) {
+	preset := buildEnvironment.WebServerSecurityHeaders
+	if preset == "" {
+		preset = "off"
+	}
+
+	values, ok := securityHeaderPresets[preset]
+	if !ok {
+		return nil, fmt.Errorf("failed: unknown BP_WEB_SERVER_SECURITY_HEADERS preset '%s', must be one of 'off', 'basic' or 'strict'", preset)
+	}
+
+	overrides := map[string]string{
+		"Content-Security-Policy":   buildEnvironment.WebServerContentSecurityPolicy,
+		"X-Frame-Options":           buildEnvironment.WebServerFrameOptions,
+		"Referrer-Policy":           buildEnvironment.WebServerReferrerPolicy,
+		"Strict-Transport-Security": buildEnvironment.WebServerStrictTransportSecurity,
+	}
+
+	var headers []SecurityHeader
+	for _, name := range securityHeaderOrder {
+		value := values[name]
+		if override := overrides[name]; override != "" {
+			value = override
+		}
+
+		if value == "" || value == "off" {
+			continue
+		}
+
+		if name == "Strict-Transport-Security" && !buildEnvironment.WebServerForceHTTPS {
+			continue
+		}
+
+		err := validateConfigValue(fmt.Sprintf("value for header '%s'", name), value)
+		if err != nil {
+			return nil, err
+		}
+
+		headers = append(headers, SecurityHeader{Name: name, Value: value})
+	}
+
+	return headers, nil
+}
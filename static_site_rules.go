@@ -0,0 +1,356 @@
+package httpd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// RedirectRule is a mod_rewrite rule translated from a line of a _redirects
+// file. Unless the rule is forced, it only applies when the request does not
+// match an existing file or directory.
+type RedirectRule struct {
+	Forced       bool
+	Pattern      string
+	Substitution string
+	Flags        string
+}
+
+// HeaderRule is a set of response headers translated from a path block of a
+// _headers file.
+type HeaderRule struct {
+	Pattern string
+	Headers []ResponseHeader
+}
+
+// ResponseHeader is a header that is set on the responses matched by a
+// HeaderRule.
+type ResponseHeader struct {
+	Name  string
+	Value string
+}
+
+// ErrorDocument is the page that is served for a response status code.
+type ErrorDocument struct {
+	Code int
+	Path string
+}
+
+// ScopedErrorDocument is the page that is served for a response status code
+// to the requests that match Pattern, translated from a 4xx rule of a
+// _redirects file.
+type ScopedErrorDocument struct {
+	Pattern string
+	Code    int
+	Path    string
+}
+
+var (
+	placeholderRegexp = regexp.MustCompile(`:([A-Za-z_][A-Za-z0-9_]*)`)
+	headerNameRegexp  = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+)
+
+// parseRedirectsFile translates the rules of the _redirects file at path into
+// mod_rewrite rules and the error documents used by rules with a 4xx status.
+// The error documents are returned in reverse order, so that the one of the
+// first matching rule is merged last and takes precedence. Lines that cannot
+// be translated are skipped and returned as warnings.
+func parseRedirectsFile(path string) ([]RedirectRule, []ScopedErrorDocument, []string, error) {
+	lines, err := readRuleLines(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var (
+		rules          []RedirectRule
+		errorDocuments []ScopedErrorDocument
+		warnings       []string
+	)
+
+	warn := func(number int, format string, a ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf("%s:%d: %s", filepath.Base(path), number, fmt.Sprintf(format, a...)))
+	}
+
+	for number, line := range lines {
+		number++
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		if len(fields) < 2 {
+			warn(number, "rule %q has no destination", line)
+			continue
+		}
+
+		from, to := fields[0], fields[1]
+		if strings.Contains(from, "://") {
+			warn(number, "domain-level redirects are not supported")
+			continue
+		}
+
+		if !strings.HasPrefix(from, "/") {
+			warn(number, "source path %q must start with '/'", from)
+			continue
+		}
+
+		if strings.Contains(to, "=") && !strings.Contains(to, "/") {
+			warn(number, "query parameter matching is not supported")
+			continue
+		}
+
+		status, forced := 301, false
+		if len(fields) > 2 {
+			code := strings.TrimSuffix(fields[2], "!")
+			forced = code != fields[2]
+
+			status, err = strconv.Atoi(code)
+			if err != nil {
+				warn(number, "status %q is not supported", fields[2])
+				continue
+			}
+		}
+
+		if len(fields) > 3 {
+			warn(number, "conditions %q are not supported", strings.Join(fields[3:], " "))
+			continue
+		}
+
+		if validateConfigValue("path", from) != nil || validateConfigValue("path", to) != nil {
+			warn(number, "paths must not contain quotes, backslashes, control characters or '${'")
+			continue
+		}
+
+		pattern, names := redirectPattern(from)
+
+		substitution, missing := redirectSubstitution(to, names)
+		if missing != "" {
+			warn(number, "placeholder :%s is not defined in the source path", missing)
+			continue
+		}
+
+		rule := RedirectRule{
+			Forced:       forced,
+			Pattern:      pattern,
+			Substitution: substitution,
+		}
+
+		switch {
+		case status == 200 && strings.Contains(to, "://"):
+			rule.Flags = "P,L"
+
+		case status == 200:
+			rule.Flags = "END"
+
+		case status >= 300 && status < 400:
+			rule.Flags = fmt.Sprintf("R=%d,L", status)
+
+		case status >= 400 && status < 500:
+			if !strings.HasPrefix(to, "/") {
+				warn(number, "the destination of a %d rule must be a path in the site", status)
+				continue
+			}
+
+			errorDocuments = append([]ScopedErrorDocument{{
+				Pattern: redirectLocationPattern(from),
+				Code:    status,
+				Path:    to,
+			}}, errorDocuments...)
+
+			rule.Substitution = "-"
+			rule.Flags = fmt.Sprintf("R=%d,L", status)
+
+		default:
+			warn(number, "status %d is not supported", status)
+			continue
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, errorDocuments, warnings, nil
+}
+
+// parseHeadersFile translates the path blocks of the _headers file at path
+// into header rules. Lines that cannot be translated are skipped and returned
+// as warnings.
+func parseHeadersFile(path string) ([]HeaderRule, []string, error) {
+	lines, err := readRuleLines(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		rules    []HeaderRule
+		current  *HeaderRule
+		warnings []string
+	)
+
+	warn := func(number int, format string, a ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf("%s:%d: %s", filepath.Base(path), number, fmt.Sprintf(format, a...)))
+	}
+
+	flush := func() {
+		if current != nil && len(current.Headers) > 0 {
+			rules = append(rules, *current)
+		}
+		current = nil
+	}
+
+	for number, line := range lines {
+		number++
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if !unicode.IsSpace(rune(line[0])) {
+			flush()
+
+			if strings.Contains(trimmed, "://") {
+				warn(number, "domain-level header rules are not supported")
+				continue
+			}
+
+			if !strings.HasPrefix(trimmed, "/") || validateConfigValue("path", trimmed) != nil || strings.ContainsAny(trimmed, " \t") {
+				warn(number, "path %q is not supported", trimmed)
+				continue
+			}
+
+			current = &HeaderRule{Pattern: fmt.Sprintf("^%s$", headerPattern(trimmed))}
+			continue
+		}
+
+		if current == nil {
+			warn(number, "header %q does not follow a path", trimmed)
+			continue
+		}
+
+		name, value, ok := strings.Cut(trimmed, ":")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || !headerNameRegexp.MatchString(name) {
+			warn(number, "header %q is not a valid 'Name: value' pair", trimmed)
+			continue
+		}
+
+		if validateConfigValue("value", value) != nil {
+			warn(number, "value of header %s must not contain quotes, backslashes, control characters or '${'", name)
+			continue
+		}
+
+		current.Headers = append(current.Headers, ResponseHeader{Name: name, Value: value})
+	}
+	flush()
+
+	return rules, warnings, nil
+}
+
+func readRuleLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	return lines, scanner.Err()
+}
+
+// redirectPattern converts a _redirects source path into a per-directory
+// mod_rewrite pattern and returns the names of its capture groups, where a
+// splat is named "splat".
+func redirectPattern(path string) (string, []string) {
+	var (
+		segments []string
+		names    []string
+		splat    bool
+	)
+
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			segments = append(segments, "([^/]+)")
+			names = append(names, segment[1:])
+
+		case strings.HasSuffix(segment, "*"):
+			segments = append(segments, regexp.QuoteMeta(strings.TrimSuffix(segment, "*"))+"(.*)")
+			names = append(names, "splat")
+			splat = true
+
+		default:
+			segments = append(segments, regexp.QuoteMeta(segment))
+		}
+	}
+
+	pattern := strings.Join(segments, "/")
+	if !splat {
+		pattern += "/?"
+	}
+
+	return fmt.Sprintf("^%s$", pattern), names
+}
+
+// redirectLocationPattern converts a _redirects source path into a
+// LocationMatch pattern that matches the same requests as redirectPattern.
+func redirectLocationPattern(path string) string {
+	pattern := headerPattern(strings.TrimSuffix(path, "/"))
+	if !strings.HasSuffix(path, "*") {
+		pattern += "/?"
+	}
+
+	return fmt.Sprintf("^%s$", pattern)
+}
+
+// redirectSubstitution replaces the placeholders in a _redirects destination
+// with back-references to the capture groups named by names. It returns the
+// name of the first placeholder that has no capture group.
+func redirectSubstitution(to string, names []string) (string, string) {
+	to = strings.NewReplacer("$", `\$`, "%", `\%`).Replace(to)
+
+	var missing string
+	substitution := placeholderRegexp.ReplaceAllStringFunc(to, func(placeholder string) string {
+		for i, name := range names {
+			if name == placeholder[1:] {
+				return fmt.Sprintf("$%d", i+1)
+			}
+		}
+
+		if missing == "" {
+			missing = placeholder[1:]
+		}
+		return placeholder
+	})
+
+	return substitution, missing
+}
+
+// headerPattern converts a _headers path into a LocationMatch pattern.
+func headerPattern(path string) string {
+	var segments []string
+	for _, segment := range strings.Split(path, "/") {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			segments = append(segments, "[^/]+")
+		default:
+			segments = append(segments, strings.ReplaceAll(regexp.QuoteMeta(segment), `\*`, ".*"))
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,50 @@
+package httpd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// webServerRootCandidates are the directories, in order of preference, that
+// are searched for an index.html when BP_WEB_SERVER_ROOT is not set.
+var webServerRootCandidates = []string{"public", "dist", "build", "_site", "out"}
+
+// resolveWebServerRoot returns the web server root to serve from workingDir.
+// A configured webServerRoot must be an existing directory and is returned
+// unchanged. Otherwise the first candidate directory that contains an
+// index.html is returned, or an empty string when there is none.
+func resolveWebServerRoot(workingDir, webServerRoot string) (string, error) {
+	if webServerRoot != "" {
+		path := webServerSiteRoot(workingDir, webServerRoot)
+
+		info, err := os.Stat(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return "", fmt.Errorf("failed: BP_WEB_SERVER_ROOT %q does not exist: set it to the directory that contains the files to serve, relative to the application root", webServerRoot)
+			}
+			return "", err
+		}
+
+		if !info.IsDir() {
+			return "", fmt.Errorf("failed: BP_WEB_SERVER_ROOT %q is not a directory", webServerRoot)
+		}
+
+		return webServerRoot, nil
+	}
+
+	for _, candidate := range webServerRootCandidates {
+		info, err := os.Stat(filepath.Join(workingDir, candidate, "index.html"))
+		if err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+
+	return "", nil
+}
+
+func containsDir(workingDir, name string) bool {
+	info, err := os.Stat(filepath.Join(workingDir, name))
+	return err == nil && info.IsDir()
+}